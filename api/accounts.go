@@ -0,0 +1,44 @@
+package api
+
+import (
+	"canvas-report/canvas"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetAccountTreeByAccountID returns the given account with its sub-accounts, recursively.
+func (c *APIController) GetAccountTreeByAccountID(w http.ResponseWriter, r *http.Request) {
+	accountID, err := strconv.Atoi(chi.URLParam(r, "account_id"))
+	if err != nil || accountID <= 0 {
+		http.Error(w, "account not found", http.StatusNotFound)
+		return
+	}
+
+	client := canvasClientFromContext(r.Context())
+
+	tree, err := client.GetAccountTree(r.Context(), accountID)
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching account tree of account: %d", accountID))
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(tree); err != nil {
+		http.Error(w, "error encoding json response", http.StatusInternalServerError)
+	}
+}
+
+// accountPathNames returns the account names from the root account down, e.g. faculty, school and department,
+// so reports can be rolled up at any level rather than by the course account alone.
+func accountPathNames(path []canvas.Account) []string {
+	names := make([]string, 0, len(path))
+
+	for _, account := range path {
+		names = append(names, account.Name)
+	}
+
+	return names
+}
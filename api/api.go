@@ -3,6 +3,7 @@ package api
 import (
 	"canvas-report/canvas"
 	"fmt"
+	"log"
 	"net/http"
 	"time"
 
@@ -12,18 +13,53 @@ import (
 )
 
 type APIController struct {
-	canvasClient *canvas.CanvasClient
-	auther       *auther
+	canvasClient *canvas.CanvasClient // default tenant, serving routes without a tenant
+	tenants      map[string]*canvas.CanvasClient
+	auther       *Auther
+	useGraphQL   bool
+
+	tokenPassthrough bool
+	sessions         SessionStore
+
+	masqueradeOperators []string
+	audit               *log.Logger
+
+	reportConcurrency int
+	gradingSLA        time.Duration
+	riskConfigs       map[int]RiskConfig // by account ID
 }
 
-func NewAPIController(canvasClient *canvas.CanvasClient, auther *auther) (*APIController, error) {
-	if canvasClient == nil {
-		return nil, fmt.Errorf("missing canvas client")
+// ControllerOption configures optional behaviour of APIController.
+type ControllerOption func(*APIController)
+
+// WithGraphQLReports makes reports that support it fetch their data with the Canvas GraphQL API,
+// which takes fewer round trips than the REST API.
+func WithGraphQLReports() ControllerOption {
+	return func(c *APIController) {
+		c.useGraphQL = true
 	}
+}
 
+// NewAPIController creates a controller serving the reports of canvasClient, and of the tenants given with WithTenant.
+// canvasClient can be nil when there are tenants, routes without a tenant then respond with 404.
+func NewAPIController(canvasClient *canvas.CanvasClient, auther *Auther, opts ...ControllerOption) (*APIController, error) {
 	controller := &APIController{
-		canvasClient: canvasClient,
-		auther:       auther,
+		canvasClient:      canvasClient,
+		auther:            auther,
+		reportConcurrency: defaultReportConcurrency,
+		gradingSLA:        defaultGradingSLA,
+	}
+
+	for _, opt := range opts {
+		opt(controller)
+	}
+
+	if controller.canvasClient == nil && len(controller.tenants) == 0 {
+		return nil, fmt.Errorf("missing canvas client")
+	}
+
+	if len(controller.masqueradeOperators) > 0 && auther == nil {
+		return nil, fmt.Errorf("masquerade operators need an auther")
 	}
 
 	return controller, nil
@@ -39,7 +75,8 @@ func NewRouter(c *APIController, allowedOrigins []string) *chi.Mux {
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   allowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "OPTIONS"},
-		AllowedHeaders:   []string{"Origin", "X-Requested-With", "Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		AllowedHeaders:   []string{"Origin", "X-Requested-With", "Accept", "Authorization", "Content-Type", "X-CSRF-Token", CanvasTokenHeader},
+		ExposedHeaders:   []string{"X-Canvas-Rate-Limit-Remaining"},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
@@ -48,12 +85,18 @@ func NewRouter(c *APIController, allowedOrigins []string) *chi.Mux {
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
+	r.Use(withFreshParam)
 
 	r.Route("/", func(r chi.Router) {
-		r.Get("/courses/{course_id}/ungraded-assignments", c.GetUngradedAssignmentsByCourseID)
-		r.Get("/users/{user_id}/student-enrollments-result", c.GetStudentEnrollmentsResultByUserID)
-		r.Get("/users/{user_id}/student-assignments-result", c.GetStudentAssignmentsResultByUserID)
-		r.Get("/users/{user_id}/ungraded-assignments", c.GetUngradedAssignmentsByUserID)
+		r.Group(func(r chi.Router) {
+			r.Use(c.withDefaultTenant)
+			c.reportRoutes(r)
+		})
+
+		r.Route("/tenants/{tenant}", func(r chi.Router) {
+			r.Use(c.withTenant)
+			c.reportRoutes(r)
+		})
 
 		r.Get("/health", healthCheck)
 	})
@@ -61,6 +104,52 @@ func NewRouter(c *APIController, allowedOrigins []string) *chi.Mux {
 	return r
 }
 
+// reportRoutes registers the report routes, served for the default tenant and under /tenants/{tenant}.
+// Reports require a JWT when the controller has an auther.
+func (c *APIController) reportRoutes(r chi.Router) {
+	if c.auther != nil {
+		r.Use(func(next http.Handler) http.Handler {
+			return withAuth(c, next.ServeHTTP)
+		})
+	}
+
+	r.Use(c.withCanvasToken)
+	r.Use(c.withMasquerade)
+	r.Use(withRateLimitHeader)
+
+	r.Get("/courses/{course_id}/ungraded-assignments", c.GetUngradedAssignmentsByCourseID)
+	r.Get("/courses/{course_id}/grading-turnaround", c.GetGradingTurnaroundByCourseID)
+	r.Get("/courses/{course_id}/missing-submissions", c.GetMissingSubmissionsByCourseID)
+	r.Get("/courses/{course_id}/grade-distribution", c.GetGradeDistributionByCourseID)
+	r.Get("/courses/{course_id}/unposted-grades", c.GetUnpostedGradesByCourseID)
+	r.Get("/users/{user_id}/student-enrollments-result", c.GetStudentEnrollmentsResultByUserID)
+	r.Get("/users/{user_id}/student-assignments-result", c.GetStudentAssignmentsResultByUserID)
+	r.Get("/users/{user_id}/ungraded-assignments", c.GetUngradedAssignmentsByUserID)
+	r.Get("/accounts/{account_id}/terms", c.GetTermsByAccountID)
+	r.Get("/accounts/{account_id}/tree", c.GetAccountTreeByAccountID)
+	r.Get("/accounts/{account_id}/ungraded-assignments", c.GetUngradedAssignmentsByAccountID)
+	r.Get("/accounts/{account_id}/teacher-backlog", c.GetTeacherBacklogByAccountID)
+	r.Get("/accounts/{account_id}/grading-turnaround", c.GetGradingTurnaroundByAccountID)
+	r.Get("/accounts/{account_id}/missing-submissions", c.GetMissingSubmissionsByAccountID)
+	r.Get("/accounts/{account_id}/at-risk-students", c.GetAtRiskStudentsByAccountID)
+	r.Get("/accounts/{account_id}/score-discrepancies", c.GetScoreDiscrepanciesByAccountID)
+	r.Get("/accounts/{account_id}/unposted-grades", c.GetUnpostedGradesByAccountID)
+}
+
+// withFreshParam is a middleware that bypasses cached Canvas responses when the request has "fresh=true",
+// for when someone needs live numbers.
+func withFreshParam(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fresh") == "true" {
+			r = r.WithContext(canvas.WithCacheBypass(r.Context()))
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
 func healthCheck(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"canvas-report/canvas"
+	"canvas-report/canvas/canvastest"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/guregu/null/v5"
+)
+
+// testFixtures is an account with a course of two sections, taught by user 10,
+// with students 1000 to 1003 in section 200 and 1004 to 1005 in section 201,
+// and assignment 300 worth 10 points.
+func testFixtures() canvastest.Fixtures {
+	fixtures := canvastest.Fixtures{
+		Accounts: []canvas.Account{{ID: 1, Name: "Science"}},
+		Courses:  []canvas.Course{{ID: 100, Name: "Math", AccountID: 1, WorkflowState: "available"}},
+		Sections: []canvas.Section{
+			{ID: 200, CourseID: 100, Name: "Section A"},
+			{ID: 201, CourseID: 100, Name: "Section B"},
+		},
+		Users: []canvas.User{{ID: 10, Name: "Teacher"}},
+		Enrollments: []canvas.Enrollment{
+			{ID: 1, UserID: 10, CourseID: 100, CourseSectionID: 200, Type: "TeacherEnrollment", Role: "TeacherEnrollment", EnrollmentState: "active"},
+		},
+		Assignments: []canvas.Assignment{
+			{ID: 300, CourseID: 100, Name: "Homework", Published: true, PointsPossible: null.FloatFrom(10)},
+		},
+	}
+
+	for i := range 6 {
+		userID := 1000 + i
+
+		sectionID := 200
+		if i >= 4 {
+			sectionID = 201
+		}
+
+		fixtures.Users = append(fixtures.Users, canvas.User{ID: userID, Name: fmt.Sprintf("Student %d", userID), SISUserID: fmt.Sprintf("S%d", userID)})
+		fixtures.Enrollments = append(fixtures.Enrollments, canvas.Enrollment{
+			ID:              10 + i,
+			UserID:          userID,
+			CourseID:        100,
+			CourseSectionID: sectionID,
+			Type:            "StudentEnrollment",
+			Role:            "StudentEnrollment",
+			EnrollmentState: "active",
+		})
+	}
+
+	return fixtures
+}
+
+// newTestServer starts a fake Canvas with the fixtures and a router serving reports from it.
+func newTestServer(t *testing.T, fixtures canvastest.Fixtures, clientOpts []canvas.ClientOption, opts ...ControllerOption) (*canvastest.Server, *chi.Mux) {
+	srv := canvastest.NewServer(fixtures)
+	t.Cleanup(srv.Close)
+
+	client, err := srv.Client(clientOpts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	controller, err := NewAPIController(client, nil, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return srv, NewRouter(controller, nil)
+}
+
+// getJSON serves a GET request and decodes the response into v, failing unless it responds with 200 OK.
+func getJSON(t *testing.T, router http.Handler, url string, v any) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s responded with %d: %s", url, rec.Code, rec.Body.String())
+	}
+
+	if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+		t.Fatalf("error decoding response of GET %s: %v", url, err)
+	}
+}
+
+// signedJWT returns a HS256 JWT of the subject, as issued by the identity provider sharing secret with the Auther.
+func signedJWT(t *testing.T, secret, subject string) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{Subject: subject}).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
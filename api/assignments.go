@@ -6,7 +6,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -17,13 +19,17 @@ type sectionWithTeachers struct {
 	sectionID    int
 	sisSectionID string
 	teachers     []string
+	teacherIDs   []int
 }
 
 type UngradedAssignment struct {
 	Account               string    `json:"account"`
+	AccountPath           []string  `json:"account_path,omitempty"` // outermost account first, with "account_path=true"
 	CourseName            string    `json:"course_name"`
 	Name                  string    `json:"name"`
+	AssignmentID          int       `json:"assignment_id"`
 	SectionName           string    `json:"section_name"`
+	SectionID             int       `json:"section_id"`
 	CourseID              int       `json:"course_id"`
 	NeedingGradingSection int       `json:"needs_grading_section"`
 	Teachers              []string  `json:"teachers"`
@@ -32,6 +38,8 @@ type UngradedAssignment struct {
 	LockAt                time.Time `json:"lock_at"`
 	Published             bool      `json:"published"`
 	GradebookURL          string    `json:"gradebook_url"`
+
+	teacherIDs []int // Canvas user IDs of Teachers, in the same order
 }
 
 type GetUngradedAssignmentsByUserIDResponse struct {
@@ -52,6 +60,7 @@ type GetUngradedAssignmentsByUserIDResponse struct {
 }
 
 // GetUngradedAssignmentsByUser returns assignments that has submission that needs to be graded.
+// Results can be filtered by "term" and "term_date".
 func (c *APIController) GetUngradedAssignmentsByUserID(w http.ResponseWriter, r *http.Request) {
 	userIDParam := chi.URLParam(r, "user_id")
 	if userIDParam == "" {
@@ -70,20 +79,28 @@ func (c *APIController) GetUngradedAssignmentsByUserID(w http.ResponseWriter, r
 		return
 	}
 
-	user, code, err := c.canvasClient.GetUserByID(userID)
+	terms, err := parseTermFilter(r)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("error fetching user: %d", userID), code)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	ctx, cancel := context.WithCancel(r.Context())
 	defer cancel()
 
+	client := canvasClientFromContext(ctx)
+
+	user, err := client.GetUserByID(ctx, userID)
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching user: %d", userID))
+		return
+	}
+
 	results := make([]*GetUngradedAssignmentsByUserIDResponse, 0)
 
-	courses, code, err := c.canvasClient.GetCoursesByUserID(ctx, user.ID)
+	courses, err := client.GetCoursesByUserID(ctx, user.ID)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("error fetching courses of user: %d", user.ID), code)
+		writeCanvasError(w, err, fmt.Sprintf("error fetching courses of user: %d", user.ID))
 		return
 	}
 
@@ -97,13 +114,13 @@ func (c *APIController) GetUngradedAssignmentsByUserID(w http.ResponseWriter, r
 
 	// skip "invited", "rejected", and "deleted" enrollments
 	states := []canvas.EnrollmentState{canvas.ActiveEnrollmentState, canvas.CompletedEnrollmentState}
-	enrollments, code, err := c.canvasClient.GetEnrollmentsByUserID(ctx, user.ID, states)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("error fetching enrollments of user: %d", user.ID), code)
-		return
-	}
 
-	for _, enrollment := range enrollments {
+	for enrollment, err := range client.IterEnrollmentsByUserID(ctx, user.ID, states) {
+		if err != nil {
+			writeCanvasError(w, err, fmt.Sprintf("error fetching enrollments of user: %d", user.ID))
+			return
+		}
+
 		select {
 		case <-ctx.Done():
 			http.Error(w, ctx.Err().Error(), http.StatusRequestTimeout)
@@ -114,17 +131,32 @@ func (c *APIController) GetUngradedAssignmentsByUserID(w http.ResponseWriter, r
 					continue
 				}
 
-				if coursesMap[enrollment.CourseID].WorkflowState != string(canvas.AvailableCourseWorkflowState) {
+				course, ok := coursesMap[enrollment.CourseID]
+				if !ok {
+					fetched, err := client.GetCourseByID(ctx, enrollment.CourseID)
+					if err != nil {
+						writeCanvasError(w, err, fmt.Sprintf("error fetching course: %d", enrollment.CourseID))
+						return
+					}
+
+					course = &fetched
+					coursesMap[enrollment.CourseID] = course
+				}
+
+				if course.WorkflowState != string(canvas.AvailableCourseWorkflowState) {
 					continue
 				}
 
-				data, code, err := c.canvasClient.GetSubmissionsByCourseID(ctx, enrollment.CourseID, user.ID, canvas.SubmittedSubmissionWorkflowState)
-				if err != nil {
-					http.Error(w, fmt.Sprintf("error fetching submissions of course: %d by user: %d", enrollment.CourseID, user.ID), code)
-					return
+				if !terms.matches(course.Term) {
+					continue
 				}
 
-				for _, submission := range data {
+				for submission, err := range client.IterSubmissionsByCourseID(ctx, enrollment.CourseID, user.ID, canvas.SubmittedSubmissionWorkflowState) {
+					if err != nil {
+						writeCanvasError(w, err, fmt.Sprintf("error fetching submissions of course: %d by user: %d", enrollment.CourseID, user.ID))
+						return
+					}
+
 					result := &GetUngradedAssignmentsByUserIDResponse{
 						AssignmentTitle: submission.Assignment.Name,
 						PointsPossible:  submission.Assignment.PointsPossible,
@@ -137,44 +169,32 @@ func (c *APIController) GetUngradedAssignmentsByUserID(w http.ResponseWriter, r
 						EnrollmentState: enrollment.EnrollmentState,
 						Status:          "on_time",
 						SpeedGraderUrl: fmt.Sprintf("%s/courses/%d/gradebook/speed_grader?assignment_id=%d&student_id=%d",
-							c.canvasClient.WebUrl, enrollment.CourseID, submission.AssignmentID, submission.UserID),
+							client.WebUrl, enrollment.CourseID, submission.AssignmentID, submission.UserID),
 					}
 
 					if submission.Late {
 						result.Status = "late"
 					}
 
-					if course, ok := coursesMap[enrollment.CourseID]; ok {
-						result.AcccountName = course.Account.Name
-						result.CourseName = course.Name
-						result.CourseState = course.WorkflowState
-					} else {
-						course, code, err := c.canvasClient.GetCourseByID(enrollment.CourseID)
-						if err != nil {
-							http.Error(w, fmt.Sprintf("error fetching course: %d", enrollment.CourseID), code)
-							return
-						}
-
-						coursesMap[enrollment.CourseID] = &course
-
-						result.AcccountName = course.Account.Name
-						result.CourseName = course.Name
-						result.CourseState = course.WorkflowState
-					}
+					result.AcccountName = course.Account.Name
+					result.CourseName = course.Name
+					result.CourseState = course.WorkflowState
 
 					results = append(results, result)
 				}
 			}
 		}
+	}
 
-		if err := json.NewEncoder(w).Encode(&results); err != nil {
-			http.Error(w, "error encoding json response", http.StatusInternalServerError)
-		}
+	if err := json.NewEncoder(w).Encode(&results); err != nil {
+		http.Error(w, "error encoding json response", http.StatusInternalServerError)
 	}
 }
 
 // GetUngradedAssignmentsByCourseID retrieves ungraded assignments in the given course ID.
 // Ungraded assignments are organised by each section within the course.
+// Nothing is returned when the course does not match the "term" and "term_date" filters.
+// With "account_path=true" every result carries the names of the course account and its parent accounts.
 func (c *APIController) GetUngradedAssignmentsByCourseID(w http.ResponseWriter, r *http.Request) {
 	courseIDParam := chi.URLParam(r, "course_id")
 	if courseIDParam == "" {
@@ -193,18 +213,181 @@ func (c *APIController) GetUngradedAssignmentsByCourseID(w http.ResponseWriter,
 		return
 	}
 
-	course, code, err := c.canvasClient.GetCourseByID(courseID)
+	terms, err := parseTermFilter(r)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("error fetching course: %d", courseID), code)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	ctx, cancel := context.WithCancel(r.Context())
 	defer cancel()
 
-	assignments, code, err := c.canvasClient.GetAssignmentsByCourseID(ctx, courseID, "", canvas.UngradedAssignmentBucket, true)
+	client := canvasClientFromContext(ctx)
+
+	course, err := client.GetCourseByID(ctx, courseID)
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching course: %d", courseID))
+		return
+	}
+
+	results := make([]*UngradedAssignment, 0)
+
+	if !terms.matches(course.Term) {
+		if err := json.NewEncoder(w).Encode(&results); err != nil {
+			http.Error(w, "error encoding json response", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	var accountPath []string
+
+	if r.URL.Query().Get("account_path") == "true" {
+		path, err := client.GetAccountPath(ctx, course.AccountID)
+		if err != nil {
+			writeCanvasError(w, err, fmt.Sprintf("error fetching account path of course: %d", courseID))
+			return
+		}
+
+		accountPath = accountPathNames(path)
+	}
+
+	results, err = ungradedAssignmentsOfCourse(ctx, client, &course, accountPath)
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching ungraded assignments of course: %d", courseID))
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&results); err != nil {
+		http.Error(w, "error encoding json response", http.StatusInternalServerError)
+	}
+}
+
+// GetUngradedAssignmentsByAccountID retrieves ungraded assignments of every course in the given account,
+// including courses of sub-accounts, fetching several courses at once.
+// Results can be filtered by "term", "term_date", "teacher" (part of a teacher name) and "min_needs_grading".
+// With "account_path=true" every result carries the names of the accounts from the given account down to the course account.
+func (c *APIController) GetUngradedAssignmentsByAccountID(w http.ResponseWriter, r *http.Request) {
+	accountID, err := strconv.Atoi(chi.URLParam(r, "account_id"))
+	if err != nil || accountID <= 0 {
+		http.Error(w, "account not found", http.StatusNotFound)
+		return
+	}
+
+	params := r.URL.Query()
+
+	terms, err := parseTermFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	teacher := strings.ToLower(strings.TrimSpace(params.Get("teacher")))
+
+	minNeedsGrading := 0
+
+	if value := params.Get("min_needs_grading"); value != "" {
+		minNeedsGrading, err = strconv.Atoi(value)
+		if err != nil || minNeedsGrading < 0 {
+			http.Error(w, "invalid min_needs_grading", http.StatusBadRequest)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	client := canvasClientFromContext(ctx)
+
+	var tree *canvas.AccountTree
+
+	if params.Get("account_path") == "true" {
+		tree, err = client.GetAccountTree(ctx, accountID)
+		if err != nil {
+			writeCanvasError(w, err, fmt.Sprintf("error fetching account tree of account: %d", accountID))
+			return
+		}
+	}
+
+	courses, err := coursesOfAccount(ctx, client, accountID, terms)
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching courses of account: %d", accountID))
+		return
+	}
+
+	resultsByCourse := make([][]*UngradedAssignment, len(courses))
+
+	err = forEach(ctx, c.reportConcurrency, len(courses), func(ctx context.Context, i int) error {
+		var accountPath []string
+
+		if tree != nil {
+			accountPath = accountPathNames(tree.Path(courses[i].AccountID))
+		}
+
+		results, err := ungradedAssignmentsOfCourse(ctx, client, courses[i], accountPath)
+		if canvas.IsNotFound(err) {
+			return nil // deleted since it was listed
+		}
+
+		if err != nil {
+			return err
+		}
+
+		resultsByCourse[i] = results
+
+		return nil
+	})
 	if err != nil {
-		http.Error(w, fmt.Sprintf("error fetching assignments of course: %d", courseID), code)
+		writeCanvasError(w, err, fmt.Sprintf("error fetching ungraded assignments of account: %d", accountID))
+		return
+	}
+
+	results := make([]*UngradedAssignment, 0)
+
+	for _, courseResults := range resultsByCourse {
+		for _, result := range courseResults {
+			if result.NeedingGradingSection < minNeedsGrading {
+				continue
+			}
+
+			if teacher != "" && !slices.ContainsFunc(result.Teachers, func(name string) bool {
+				return strings.Contains(strings.ToLower(name), teacher)
+			}) {
+				continue
+			}
+
+			results = append(results, result)
+		}
+	}
+
+	if err := json.NewEncoder(w).Encode(&results); err != nil {
+		http.Error(w, "error encoding json response", http.StatusInternalServerError)
+	}
+}
+
+// coursesOfAccount returns the courses of the account matching the term filter.
+// Canvas lists courses of sub-accounts along with the courses of the account.
+func coursesOfAccount(ctx context.Context, client *canvas.CanvasClient, accountID int, terms termFilter) ([]*canvas.Course, error) {
+	courses := make([]*canvas.Course, 0)
+
+	for course, err := range client.IterCoursesByAccountID(ctx, accountID, "", nil) {
+		if err != nil {
+			return nil, err
+		}
+
+		if terms.matches(course.Term) {
+			courses = append(courses, course)
+		}
+	}
+
+	return courses, nil
+}
+
+// ungradedAssignmentsOfCourse returns the ungraded assignments of the course, one per section needing grading,
+// with the section teachers and the section dates.
+func ungradedAssignmentsOfCourse(ctx context.Context, client *canvas.CanvasClient, course *canvas.Course, accountPath []string) ([]*UngradedAssignment, error) {
+	assignments, err := client.GetAssignmentsByCourseID(ctx, course.ID, "", canvas.UngradedAssignmentBucket, true)
+	if err != nil {
+		return nil, err
 	}
 
 	sectionWithTeachersBySectionID := make(map[int]sectionWithTeachers)
@@ -214,8 +397,7 @@ func (c *APIController) GetUngradedAssignmentsByCourseID(w http.ResponseWriter,
 	for _, assignment := range assignments {
 		select {
 		case <-ctx.Done():
-			http.Error(w, ctx.Err().Error(), http.StatusRequestTimeout)
-			return
+			return nil, ctx.Err()
 		default:
 			{
 				for _, section := range assignment.NeedsGradingCountBySection {
@@ -231,21 +413,23 @@ func (c *APIController) GetUngradedAssignmentsByCourseID(w http.ResponseWriter,
 					// no section information at the moment
 					if _, ok := sectionWithTeachersBySectionID[section.SectionID]; !ok {
 
-						enrollments, code, err := c.canvasClient.GetEnrollmentsBySectionID(ctx, section.SectionID, nil, []canvas.EnrollmentType{canvas.TeacherEnrollmentType})
+						enrollments, err := client.GetEnrollmentsBySectionID(ctx, section.SectionID, nil, []canvas.EnrollmentType{canvas.TeacherEnrollmentType})
 						if err != nil {
-							http.Error(w, fmt.Sprintf("error fetching enrollments of section: %d", section.SectionID), code)
-							return
+							return nil, err
 						}
 
 						teachers := []string{}
+						teacherIDs := []int{}
 
 						for _, enrollment := range enrollments {
 							teachers = append(teachers, enrollment.User.Name)
+							teacherIDs = append(teacherIDs, enrollment.UserID)
 						}
 
 						st := sectionWithTeachers{
-							sectionID: section.SectionID,
-							teachers:  teachers,
+							sectionID:  section.SectionID,
+							teachers:   teachers,
+							teacherIDs: teacherIDs,
 						}
 
 						// there are teachers in the section
@@ -255,9 +439,9 @@ func (c *APIController) GetUngradedAssignmentsByCourseID(w http.ResponseWriter,
 
 						// get section when there is no sis section id
 						if st.sisSectionID == "" {
-							_section, code, err := c.canvasClient.GetSectionByID(section.SectionID)
+							_section, err := client.GetSectionByID(ctx, section.SectionID)
 							if err != nil {
-								http.Error(w, fmt.Sprintf("error fetching section: %d", section.SectionID), code)
+								return nil, err
 							}
 
 							st.sisSectionID = _section.Name
@@ -268,18 +452,22 @@ func (c *APIController) GetUngradedAssignmentsByCourseID(w http.ResponseWriter,
 
 					result := &UngradedAssignment{
 						Name:                  assignment.Name,
+						AssignmentID:          assignment.ID,
+						SectionID:             section.SectionID,
 						CourseID:              assignment.CourseID,
 						NeedingGradingSection: section.NeedsGradingCount,
 						Published:             assignment.Published,
 						Account:               course.Account.Name,
+						AccountPath:           accountPath,
 						CourseName:            course.Name,
-						GradebookURL:          fmt.Sprintf(`%s/courses/%d/gradebook`, c.canvasClient.WebUrl, courseID),
+						GradebookURL:          fmt.Sprintf(`%s/courses/%d/gradebook`, client.WebUrl, course.ID),
 					}
 
 					// now we have section information
 					if st, ok := sectionWithTeachersBySectionID[section.SectionID]; ok {
 						result.SectionName = st.sisSectionID
 						result.Teachers = st.teachers
+						result.teacherIDs = st.teacherIDs
 					}
 
 					// section has date
@@ -295,9 +483,7 @@ func (c *APIController) GetUngradedAssignmentsByCourseID(w http.ResponseWriter,
 		}
 	}
 
-	if err := json.NewEncoder(w).Encode(&results); err != nil {
-		http.Error(w, "error encoding json response", http.StatusInternalServerError)
-	}
+	return results, nil
 }
 
 type AssignmentResult struct {
@@ -316,9 +502,11 @@ type AssignmentResult struct {
 	CourseState     string     `json:"course_state"`
 	EnrollmentRole  string     `json:"enrollment_role"`
 	EnrollmentState string     `json:"enrollment_state"`
+	ReportTerm
 }
 
 // GetStudentAssignmentsResultByUserID retrieves assignments result of the given student in respective enrolled courses.
+// Results can be filtered by "term" and "term_date", and grouped by term with "group_by=term".
 func (c *APIController) GetStudentAssignmentsResultByUserID(w http.ResponseWriter, r *http.Request) {
 	userIDParam := chi.URLParam(r, "user_id")
 	if userIDParam == "" {
@@ -332,17 +520,37 @@ func (c *APIController) GetStudentAssignmentsResultByUserID(w http.ResponseWrite
 		return
 	}
 
-	user, code, err := c.canvasClient.GetUserByID(userID)
+	terms, err := parseTermFilter(r)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("error fetching user: %d", userID), code)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	grouped, err := parseGroupByTerm(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	ctx, cancel := context.WithCancel(r.Context())
 	defer cancel()
 
-	courses, code, err := c.canvasClient.GetCoursesByUserID(ctx, userID)
+	client := canvasClientFromContext(ctx)
+
+	if c.useGraphQL {
+		c.getStudentAssignmentsResultByUserIDWithGraphQL(ctx, client, w, userID, terms, grouped)
+		return
+	}
+
+	user, err := client.GetUserByID(ctx, userID)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("error fetching courses of user: %d", userID), code)
+		writeCanvasError(w, err, fmt.Sprintf("error fetching user: %d", userID))
+		return
+	}
+
+	courses, err := client.GetCoursesByUserID(ctx, userID)
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching courses of user: %d", userID))
 		return
 	}
 
@@ -360,14 +568,13 @@ func (c *APIController) GetStudentAssignmentsResultByUserID(w http.ResponseWrite
 	// so skip those enrollments
 	states := []canvas.EnrollmentState{canvas.ActiveEnrollmentState, canvas.CompletedEnrollmentState}
 
-	enrollments, code, err := c.canvasClient.GetEnrollmentsByUserID(ctx, userID, states)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("error fetching enrollments of user: %d", userID), code)
-		return
-	}
-
 loop:
-	for _, enrollment := range enrollments {
+	for enrollment, err := range client.IterEnrollmentsByUserID(ctx, userID, states) {
+		if err != nil {
+			writeCanvasError(w, err, fmt.Sprintf("error fetching enrollments of user: %d", userID))
+			return
+		}
+
 		select {
 		case <-ctx.Done():
 			http.Error(w, ctx.Err().Error(), http.StatusRequestTimeout)
@@ -378,17 +585,32 @@ loop:
 					continue loop
 				}
 
-				if courseByCourseID[enrollment.CourseID].WorkflowState != string(canvas.AvailableCourseWorkflowState) {
+				course, ok := courseByCourseID[enrollment.CourseID]
+				if !ok {
+					fetched, err := client.GetCourseByID(ctx, enrollment.CourseID)
+					if err != nil {
+						writeCanvasError(w, err, fmt.Sprintf("error fetching course: %d", enrollment.CourseID))
+						return
+					}
+
+					course = &fetched
+					courseByCourseID[enrollment.CourseID] = course
+				}
+
+				if course.WorkflowState != string(canvas.AvailableCourseWorkflowState) {
 					continue
 				}
 
-				data, code, err := c.canvasClient.GetAssignmentsDataOfUserByCourseID(ctx, userID, enrollment.CourseID)
-				if err != nil {
-					http.Error(w, fmt.Sprintf("error fetching assignment results of user: %d and course: %d", userID, enrollment.CourseID), code)
-					return
+				if !terms.matches(course.Term) {
+					continue
 				}
 
-				for _, ad := range data {
+				for ad, err := range client.IterAssignmentsDataOfUserByCourseID(ctx, userID, enrollment.CourseID) {
+					if err != nil {
+						writeCanvasError(w, err, fmt.Sprintf("error fetching assignment results of user: %d and course: %d", userID, enrollment.CourseID))
+						return
+					}
+
 					result := &AssignmentResult{
 						Title:           ad.Title,
 						PointsPossible:  ad.PointsPossible,
@@ -408,31 +630,87 @@ loop:
 						result.Discrepancy = "ERROR"
 					}
 
-					if course, ok := courseByCourseID[enrollment.CourseID]; ok {
-						result.Acccount = course.Account.Name
-						result.CourseName = course.Name
-						result.CourseState = course.WorkflowState
+					result.Acccount = course.Account.Name
+					result.CourseName = course.Name
+					result.CourseState = course.WorkflowState
+					result.ReportTerm = newReportTerm(course.Term)
 
-					} else {
-						course, code, err := c.canvasClient.GetCourseByID(enrollment.CourseID)
-						if err != nil {
-							http.Error(w, fmt.Sprintf("error fetching course: %d", enrollment.CourseID), code)
-							return
-						}
+					results = append(results, result)
+				}
+			}
+		}
+	}
 
-						courseByCourseID[enrollment.CourseID] = &course
+	if grouped {
+		groupByTerm(results)
+	}
 
-						result.Acccount = course.Account.Name
-						result.CourseName = course.Name
-						result.CourseState = course.WorkflowState
-					}
+	if err := json.NewEncoder(w).Encode(&results); err != nil {
+		http.Error(w, "error encoding json response", http.StatusInternalServerError)
+	}
+}
 
-					results = append(results, result)
-				}
+// getStudentAssignmentsResultByUserIDWithGraphQL builds the same report as GetStudentAssignmentsResultByUserID
+// from a single Canvas GraphQL query instead of one REST call per course.
+func (c *APIController) getStudentAssignmentsResultByUserIDWithGraphQL(ctx context.Context, client *canvas.CanvasClient, w http.ResponseWriter, userID int, terms termFilter, grouped bool) {
+	report, err := client.GetStudentReportByUserID(ctx, userID)
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching assignment results of user: %d", userID))
+		return
+	}
+
+	results := make([]*AssignmentResult, 0)
+
+	for _, enrollment := range report.Enrollments {
+		if enrollment.EnrollmentType != string(canvas.StudentEnrollmentType) {
+			continue
+		}
+
+		// same as the REST report, skip "invited", "rejected", and "deleted" enrollments
+		if enrollment.EnrollmentState != string(canvas.ActiveEnrollmentState) && enrollment.EnrollmentState != string(canvas.CompletedEnrollmentState) {
+			continue
+		}
+
+		if enrollment.Course.State != string(canvas.AvailableCourseWorkflowState) {
+			continue
+		}
+
+		if !terms.matches(enrollment.Course.Term) {
+			continue
+		}
+
+		for _, submission := range enrollment.Submissions {
+			result := &AssignmentResult{
+				Title:           submission.Title,
+				PointsPossible:  submission.PointsPossible,
+				DueAt:           submission.DueAt.String,
+				Score:           submission.Score,
+				SubmittedAt:     submission.SubmittedAt.String,
+				UserSisID:       report.SISUserID.String,
+				Name:            report.Name,
+				Acccount:        enrollment.Course.AccountName,
+				CourseName:      enrollment.Course.Name,
+				CourseState:     enrollment.Course.State,
+				Section:         enrollment.Section.SISID.String,
+				EnrollmentRole:  enrollment.EnrollmentType,
+				EnrollmentState: enrollment.EnrollmentState,
+				Status:          submission.Status(),
+				ReportTerm:      newReportTerm(enrollment.Course.Term),
 			}
+
+			// Check for situation where student got more marks than possible
+			if submission.Score.Float64 > submission.PointsPossible.Float64 {
+				result.Discrepancy = "ERROR"
+			}
+
+			results = append(results, result)
 		}
 	}
 
+	if grouped {
+		groupByTerm(results)
+	}
+
 	if err := json.NewEncoder(w).Encode(&results); err != nil {
 		http.Error(w, "error encoding json response", http.StatusInternalServerError)
 	}
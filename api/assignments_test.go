@@ -0,0 +1,181 @@
+package api
+
+import (
+	"canvas-report/canvas"
+	"canvas-report/canvas/canvastest"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/guregu/null/v5"
+)
+
+// needsGradingBySection is the needs grading count of an assignment in a section.
+type needsGradingBySection = struct {
+	SectionID         int `json:"section_id"`
+	NeedsGradingCount int `json:"needs_grading_count"`
+}
+
+// ungradedFixtures extends testFixtures with a Fall term for course 100, where assignment 300 needs grading
+// in both sections, and course 101 of the Physics sub-account in the Spring term, taught by Ada Lovelace,
+// where assignment 310 needs grading and assignment 311 is graded.
+func ungradedFixtures() canvastest.Fixtures {
+	fixtures := testFixtures()
+
+	fixtures.Accounts = append(fixtures.Accounts, canvas.Account{ID: 2, Name: "Physics", ParentAccountID: null.IntFrom(1)})
+	fixtures.Terms = []canvas.Term{
+		{ID: 1, Name: "Fall", StartAt: null.TimeFrom(time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC))},
+		{ID: 2, Name: "Spring", StartAt: null.TimeFrom(time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC))},
+	}
+
+	fixtures.Courses[0].EnrollmentTermID = 1
+	fixtures.Courses = append(fixtures.Courses, canvas.Course{ID: 101, Name: "Optics", AccountID: 2, WorkflowState: "available", EnrollmentTermID: 2})
+	fixtures.Sections = append(fixtures.Sections, canvas.Section{ID: 210, CourseID: 101, Name: "Section O"})
+	fixtures.Users = append(fixtures.Users, canvas.User{ID: 11, Name: "Ada Lovelace"})
+	fixtures.Enrollments = append(fixtures.Enrollments, canvas.Enrollment{
+		ID: 30, UserID: 11, CourseID: 101, CourseSectionID: 210, SISSectionID: null.StringFrom("OPT-1"),
+		Type: "TeacherEnrollment", Role: "TeacherEnrollment", EnrollmentState: "active",
+	})
+
+	fixtures.Assignments[0].NeedsGradingCount = 4
+	fixtures.Assignments[0].NeedsGradingCountBySection = []needsGradingBySection{{SectionID: 200, NeedsGradingCount: 3}, {SectionID: 201, NeedsGradingCount: 1}}
+	fixtures.Assignments = append(fixtures.Assignments,
+		canvas.Assignment{
+			ID: 310, CourseID: 101, Name: "Lab", Published: true, PointsPossible: null.FloatFrom(10),
+			NeedsGradingCount: 5, NeedsGradingCountBySection: []needsGradingBySection{{SectionID: 210, NeedsGradingCount: 5}},
+		},
+		canvas.Assignment{ID: 311, CourseID: 101, Name: "Quiz", Published: true, PointsPossible: null.FloatFrom(10)},
+	)
+
+	return fixtures
+}
+
+func TestGetUngradedAssignmentsByAccountID(t *testing.T) {
+	_, router := newTestServer(t, ungradedFixtures(), nil, WithReportConcurrency(2))
+
+	// want the assignment and section of every result
+	tests := []struct {
+		url  string
+		want []string
+	}{
+		{url: "/accounts/1/ungraded-assignments", want: []string{"300/200", "300/201", "310/210"}},
+		{url: "/accounts/2/ungraded-assignments", want: []string{"310/210"}},
+		{url: "/accounts/1/ungraded-assignments?teacher=ada", want: []string{"310/210"}},
+		{url: "/accounts/1/ungraded-assignments?teacher=%20TEACHER%20", want: []string{"300/200"}},
+		{url: "/accounts/1/ungraded-assignments?teacher=nobody", want: []string{}},
+		{url: "/accounts/1/ungraded-assignments?min_needs_grading=3", want: []string{"300/200", "310/210"}},
+		{url: "/accounts/1/ungraded-assignments?min_needs_grading=6", want: []string{}},
+		{url: "/accounts/1/ungraded-assignments?term=Spring", want: []string{"310/210"}},
+		{url: "/accounts/1/ungraded-assignments?term_date=2025-10-01", want: []string{"300/200", "300/201"}},
+		{url: "/accounts/1/ungraded-assignments?term=Fall&min_needs_grading=2", want: []string{"300/200"}},
+		{url: "/accounts/1/ungraded-assignments?term=Fall&teacher=ada", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			var results []UngradedAssignment
+
+			getJSON(t, router, tt.url, &results)
+
+			got := make([]string, 0, len(results))
+
+			for _, result := range results {
+				got = append(got, fmt.Sprintf("%d/%d", result.AssignmentID, result.SectionID))
+			}
+
+			slices.Sort(got)
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got results %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, url := range []string{
+		"/accounts/1/ungraded-assignments?min_needs_grading=-1",
+		"/accounts/1/ungraded-assignments?min_needs_grading=many",
+		"/accounts/1/ungraded-assignments?term_date=fall",
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %s responded with %d, want 400", url, rec.Code)
+		}
+	}
+
+	var results []UngradedAssignment
+
+	getJSON(t, router, "/accounts/1/ungraded-assignments?teacher=ada&account_path=true", &results)
+
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+
+	result := results[0]
+
+	if !slices.Equal(result.AccountPath, []string{"Science", "Physics"}) || result.Account != "Physics" || result.CourseName != "Optics" {
+		t.Errorf("got account %q with path %v of course %q, want Physics below Science of Optics", result.Account, result.AccountPath, result.CourseName)
+	}
+
+	if result.SectionName != "OPT-1" || result.NeedingGradingSection != 5 || !slices.Equal(result.Teachers, []string{"Ada Lovelace"}) {
+		t.Errorf("got section %q needing %d grades taught by %v, want OPT-1 needing 5 taught by Ada Lovelace", result.SectionName, result.NeedingGradingSection, result.Teachers)
+	}
+}
+
+func TestGetUngradedAssignmentsByUserID(t *testing.T) {
+	fixtures := ungradedFixtures()
+
+	// student 1000 concluded Optics, so Canvas does not list it among the courses of the user,
+	// and is enrolled in Chemistry which is no longer available
+	fixtures.Courses = append(fixtures.Courses, canvas.Course{ID: 102, Name: "Chemistry", AccountID: 1, WorkflowState: "completed", EnrollmentTermID: 1})
+	fixtures.Sections = append(fixtures.Sections, canvas.Section{ID: 220, CourseID: 102, Name: "Section C"})
+	fixtures.Enrollments = append(fixtures.Enrollments,
+		canvas.Enrollment{ID: 31, UserID: 1000, CourseID: 101, CourseSectionID: 210, Type: "StudentEnrollment", Role: "StudentEnrollment", EnrollmentState: "completed"},
+		canvas.Enrollment{ID: 32, UserID: 1000, CourseID: 102, CourseSectionID: 220, Type: "StudentEnrollment", Role: "StudentEnrollment", EnrollmentState: "active"},
+	)
+	fixtures.Assignments = append(fixtures.Assignments, canvas.Assignment{ID: 320, CourseID: 102, Name: "Titration", Published: true})
+
+	submittedAt := time.Date(2026, 2, 1, 12, 0, 0, 0, time.UTC).Format(time.RFC3339)
+
+	fixtures.Submissions = []canvas.Submission{
+		{ID: 1, UserID: 1000, AssignmentID: 300, WorkflowState: "submitted", SubmittedAt: null.StringFrom(submittedAt)},
+		{ID: 2, UserID: 1000, AssignmentID: 310, WorkflowState: "submitted", SubmittedAt: null.StringFrom(submittedAt), Late: true},
+		{ID: 3, UserID: 1000, AssignmentID: 311, WorkflowState: "graded", SubmittedAt: null.StringFrom(submittedAt), Score: null.FloatFrom(7)},
+		{ID: 4, UserID: 1000, AssignmentID: 320, WorkflowState: "submitted", SubmittedAt: null.StringFrom(submittedAt)},
+	}
+
+	_, router := newTestServer(t, fixtures, nil)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "", want: []string{"Math/Homework/on_time", "Optics/Lab/late"}},
+		{query: "?term=Spring", want: []string{"Optics/Lab/late"}},
+		{query: "?term=Summer", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var results []GetUngradedAssignmentsByUserIDResponse
+
+			getJSON(t, router, "/users/1000/ungraded-assignments"+tt.query, &results)
+
+			got := make([]string, 0, len(results))
+
+			for _, result := range results {
+				got = append(got, fmt.Sprintf("%s/%s/%s", result.CourseName, result.AssignmentTitle, result.Status))
+			}
+
+			slices.Sort(got)
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got results %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
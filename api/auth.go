@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
@@ -8,7 +9,8 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-type auther struct {
+// Auther validates the JWTs of API requests.
+type Auther struct {
 	secret []byte
 	issuer string
 }
@@ -17,7 +19,7 @@ type claims struct {
 	jwt.RegisteredClaims
 }
 
-func NewAuther(secret, issuer string) (*auther, error) {
+func NewAuther(secret, issuer string) (*Auther, error) {
 	if secret == "" {
 		return nil, fmt.Errorf("missing jwt secret")
 	}
@@ -26,7 +28,7 @@ func NewAuther(secret, issuer string) (*auther, error) {
 		return nil, fmt.Errorf("missing jwt issuer")
 	}
 
-	auther := &auther{
+	auther := &Auther{
 		secret: []byte(secret),
 		issuer: issuer,
 	}
@@ -36,7 +38,7 @@ func NewAuther(secret, issuer string) (*auther, error) {
 
 // parseJwtToken checks validity of token and returns jwt subject.
 // Validty is checked for HS256 algorithm.
-func (a *auther) parseJwtToken(token string) (string, error) {
+func (a *Auther) parseJwtToken(token string) (string, error) {
 	t, err := jwt.ParseWithClaims(token, &claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -56,10 +58,19 @@ func (a *auther) parseJwtToken(token string) (string, error) {
 	return "", fmt.Errorf("error parsing token: %v", err)
 }
 
+type subjectKey struct{}
+
+// subjectFromContext returns the JWT subject of the request authenticated by withAuth,
+// or empty string if the request was not authenticated.
+func subjectFromContext(ctx context.Context) string {
+	subject, _ := ctx.Value(subjectKey{}).(string)
+	return subject
+}
+
 // withAuth is a middleware that ensures the request is authenticated before allowing access to the next handler.
 // It checks the presence and validity of the Authorization header, expecting a Bearer token format.
 // If the Authorization header is missing, invalid, or the JWT token is not valid, it responds with a 401 Unauthorized error.
-// If the token is valid, it proceeds to the next handler.
+// If the token is valid, it proceeds to the next handler with the JWT subject in the request context.
 func withAuth(c *APIController, next http.HandlerFunc) http.HandlerFunc {
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
@@ -82,13 +93,13 @@ func withAuth(c *APIController, next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		_, err := c.auther.parseJwtToken(token)
+		subject, err := c.auther.parseJwtToken(token)
 		if err != nil {
 			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
 			return
 		}
 
-		next(w, r)
+		next(w, r.WithContext(context.WithValue(r.Context(), subjectKey{}, subject)))
 	}
 
 	return fn
@@ -0,0 +1,259 @@
+package api
+
+import (
+	"canvas-report/canvas"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/guregu/null/v5"
+)
+
+// TeacherBacklogItem is an assignment of a section with submissions needing grading.
+type TeacherBacklogItem struct {
+	CourseID          int       `json:"course_id"`
+	CourseName        string    `json:"course_name"`
+	AssignmentID      int       `json:"assignment_id"`
+	AssignmentName    string    `json:"assignment_name"`
+	SectionID         int       `json:"section_id"`
+	SectionName       string    `json:"section_name"`
+	NeedsGrading      int       `json:"needs_grading"`
+	OldestSubmittedAt null.Time `json:"oldest_submitted_at"`
+	SpeedGraderUrl    string    `json:"speedgrader_url"` // opens the oldest pending submission when known
+
+	teacherIDs []int
+	teachers   []string
+}
+
+// TeacherBacklog is the grading backlog of a teacher across the sections they teach.
+type TeacherBacklog struct {
+	TeacherID         int                   `json:"teacher_id"`
+	TeacherName       string                `json:"teacher_name"`
+	NeedsGrading      int                   `json:"needs_grading"`
+	OldestSubmittedAt null.Time             `json:"oldest_submitted_at"`
+	OldestPendingDays int                   `json:"oldest_pending_days"`
+	Courses           []string              `json:"courses"`
+	Items             []*TeacherBacklogItem `json:"items"`
+}
+
+// GetTeacherBacklogByAccountID retrieves the grading backlog of every teacher of the courses in the given account,
+// including courses of sub-accounts, teachers with the most submissions needing grading first.
+// Co-teachers of a section share its items. Items of sections without a teacher are reported under teacher ID 0.
+// Results can be filtered by "term", "term_date" and "teacher" (part of a teacher name).
+func (c *APIController) GetTeacherBacklogByAccountID(w http.ResponseWriter, r *http.Request) {
+	accountID, err := strconv.Atoi(chi.URLParam(r, "account_id"))
+	if err != nil || accountID <= 0 {
+		http.Error(w, "account not found", http.StatusNotFound)
+		return
+	}
+
+	terms, err := parseTermFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	teacher := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("teacher")))
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	client := canvasClientFromContext(ctx)
+
+	courses, err := coursesOfAccount(ctx, client, accountID, terms)
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching courses of account: %d", accountID))
+		return
+	}
+
+	itemsByCourse := make([][]*TeacherBacklogItem, len(courses))
+
+	err = forEach(ctx, c.reportConcurrency, len(courses), func(ctx context.Context, i int) error {
+		items, err := backlogItemsOfCourse(ctx, client, courses[i])
+		if canvas.IsNotFound(err) {
+			return nil // deleted since it was listed
+		}
+
+		if err != nil {
+			return err
+		}
+
+		itemsByCourse[i] = items
+
+		return nil
+	})
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching teacher backlog of account: %d", accountID))
+		return
+	}
+
+	results := make([]*TeacherBacklog, 0)
+	backlogByTeacherID := make(map[int]*TeacherBacklog)
+
+	add := func(teacherID int, teacherName string, item *TeacherBacklogItem) {
+		backlog, ok := backlogByTeacherID[teacherID]
+		if !ok {
+			backlog = &TeacherBacklog{TeacherID: teacherID, TeacherName: teacherName, Courses: []string{}}
+			backlogByTeacherID[teacherID] = backlog
+			results = append(results, backlog)
+		}
+
+		backlog.NeedsGrading += item.NeedsGrading
+		backlog.Items = append(backlog.Items, item)
+
+		if !slices.Contains(backlog.Courses, item.CourseName) {
+			backlog.Courses = append(backlog.Courses, item.CourseName)
+		}
+
+		if item.OldestSubmittedAt.Valid && (!backlog.OldestSubmittedAt.Valid || item.OldestSubmittedAt.Time.Before(backlog.OldestSubmittedAt.Time)) {
+			backlog.OldestSubmittedAt = item.OldestSubmittedAt
+		}
+	}
+
+	for _, items := range itemsByCourse {
+		for _, item := range items {
+			if len(item.teacherIDs) == 0 {
+				add(0, "", item)
+				continue
+			}
+
+			for i, teacherID := range item.teacherIDs {
+				add(teacherID, item.teachers[i], item)
+			}
+		}
+	}
+
+	if teacher != "" {
+		results = slices.DeleteFunc(results, func(backlog *TeacherBacklog) bool {
+			return !strings.Contains(strings.ToLower(backlog.TeacherName), teacher)
+		})
+	}
+
+	now := time.Now()
+
+	for _, backlog := range results {
+		if backlog.OldestSubmittedAt.Valid {
+			backlog.OldestPendingDays = int(now.Sub(backlog.OldestSubmittedAt.Time).Hours() / 24)
+		}
+
+		slices.SortStableFunc(backlog.Items, func(a, b *TeacherBacklogItem) int {
+			return compareOldest(a.OldestSubmittedAt, b.OldestSubmittedAt)
+		})
+	}
+
+	slices.SortStableFunc(results, func(a, b *TeacherBacklog) int {
+		if c := cmp.Compare(b.NeedsGrading, a.NeedsGrading); c != 0 {
+			return c
+		}
+
+		if c := compareOldest(a.OldestSubmittedAt, b.OldestSubmittedAt); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(a.TeacherName, b.TeacherName)
+	})
+
+	if err := json.NewEncoder(w).Encode(&results); err != nil {
+		http.Error(w, "error encoding json response", http.StatusInternalServerError)
+	}
+}
+
+// compareOldest orders earlier times first, and missing times last.
+func compareOldest(a, b null.Time) int {
+	if a.Valid != b.Valid {
+		if a.Valid {
+			return -1
+		}
+
+		return 1
+	}
+
+	return a.Time.Compare(b.Time)
+}
+
+type pendingSubmission struct {
+	submittedAt time.Time
+	userID      int
+}
+
+// backlogItemsOfCourse returns the ungraded assignments of the course, one per section needing grading,
+// with the oldest submission pending grading of the section.
+func backlogItemsOfCourse(ctx context.Context, client *canvas.CanvasClient, course *canvas.Course) ([]*TeacherBacklogItem, error) {
+	ungraded, err := ungradedAssignmentsOfCourse(ctx, client, course, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// oldest pending submission by assignment ID, of each section
+	oldestBySectionID := make(map[int]map[int]pendingSubmission)
+
+	items := make([]*TeacherBacklogItem, 0, len(ungraded))
+
+	for _, assignment := range ungraded {
+		oldest, ok := oldestBySectionID[assignment.SectionID]
+		if !ok {
+			oldest, err = oldestPendingSubmissionsOfSection(ctx, client, assignment.SectionID)
+			if err != nil {
+				return nil, err
+			}
+
+			oldestBySectionID[assignment.SectionID] = oldest
+		}
+
+		item := &TeacherBacklogItem{
+			CourseID:       course.ID,
+			CourseName:     course.Name,
+			AssignmentID:   assignment.AssignmentID,
+			AssignmentName: assignment.Name,
+			SectionID:      assignment.SectionID,
+			SectionName:    assignment.SectionName,
+			NeedsGrading:   assignment.NeedingGradingSection,
+			SpeedGraderUrl: fmt.Sprintf("%s/courses/%d/gradebook/speed_grader?assignment_id=%d",
+				client.WebUrl, course.ID, assignment.AssignmentID),
+			teacherIDs: assignment.teacherIDs,
+			teachers:   assignment.Teachers,
+		}
+
+		if submission, ok := oldest[assignment.AssignmentID]; ok {
+			item.OldestSubmittedAt = null.TimeFrom(submission.submittedAt)
+			item.SpeedGraderUrl += fmt.Sprintf("&student_id=%d", submission.userID)
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// oldestPendingSubmissionsOfSection returns the oldest submission needing grading of each assignment by assignment ID.
+func oldestPendingSubmissionsOfSection(ctx context.Context, client *canvas.CanvasClient, sectionID int) (map[int]pendingSubmission, error) {
+	oldest := make(map[int]pendingSubmission)
+
+	states := []canvas.SubmissionWorkflowState{canvas.SubmittedSubmissionWorkflowState, canvas.PendingReviewSubmissionWorkflowState}
+
+	for _, state := range states {
+		for submission, err := range client.IterSubmissionsBySectionID(ctx, sectionID, state) {
+			if err != nil {
+				return nil, err
+			}
+
+			submittedAt, err := time.Parse(time.RFC3339, submission.SubmittedAt.String)
+			if err != nil {
+				continue // never submitted, e.g. graded on paper
+			}
+
+			if current, ok := oldest[submission.AssignmentID]; !ok || submittedAt.Before(current.submittedAt) {
+				oldest[submission.AssignmentID] = pendingSubmission{submittedAt: submittedAt, userID: submission.UserID}
+			}
+		}
+	}
+
+	return oldest, nil
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"canvas-report/canvas"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guregu/null/v5"
+)
+
+func TestGetTeacherBacklogByAccountID(t *testing.T) {
+	fixtures := testFixtures()
+
+	// Teacher teaches section 200 of Math and section 210 of Optics, Ada and Grace co-teach section 211,
+	// and section 201 has no teacher
+	fixtures.Courses = append(fixtures.Courses, canvas.Course{ID: 101, Name: "Optics", AccountID: 1, WorkflowState: "available"})
+	fixtures.Sections = append(fixtures.Sections,
+		canvas.Section{ID: 210, CourseID: 101, Name: "Section O1"},
+		canvas.Section{ID: 211, CourseID: 101, Name: "Section O2"},
+	)
+	fixtures.Users = append(fixtures.Users, canvas.User{ID: 11, Name: "Ada Lovelace"}, canvas.User{ID: 12, Name: "Grace Hopper"})
+	fixtures.Enrollments = append(fixtures.Enrollments,
+		canvas.Enrollment{ID: 30, UserID: 10, CourseID: 101, CourseSectionID: 210, Type: "TeacherEnrollment", Role: "TeacherEnrollment", EnrollmentState: "active"},
+		canvas.Enrollment{ID: 31, UserID: 11, CourseID: 101, CourseSectionID: 211, Type: "TeacherEnrollment", Role: "TeacherEnrollment", EnrollmentState: "active"},
+		canvas.Enrollment{ID: 32, UserID: 12, CourseID: 101, CourseSectionID: 211, Type: "TeacherEnrollment", Role: "TeacherEnrollment", EnrollmentState: "active"},
+	)
+
+	for i, sectionID := range []int{210, 211, 211} {
+		fixtures.Enrollments = append(fixtures.Enrollments, canvas.Enrollment{
+			ID: 40 + i, UserID: 1006 + i, CourseID: 101, CourseSectionID: sectionID, Type: "StudentEnrollment", Role: "StudentEnrollment", EnrollmentState: "active",
+		})
+	}
+
+	fixtures.Assignments[0].NeedsGradingCount = 3
+	fixtures.Assignments[0].NeedsGradingCountBySection = []needsGradingBySection{{SectionID: 200, NeedsGradingCount: 2}, {SectionID: 201, NeedsGradingCount: 1}}
+	fixtures.Assignments = append(fixtures.Assignments,
+		canvas.Assignment{
+			ID: 310, CourseID: 101, Name: "Lab", Published: true,
+			NeedsGradingCount: 3, NeedsGradingCountBySection: []needsGradingBySection{{SectionID: 210, NeedsGradingCount: 1}, {SectionID: 211, NeedsGradingCount: 2}},
+		},
+		canvas.Assignment{
+			ID: 311, CourseID: 101, Name: "Report", Published: true,
+			NeedsGradingCount: 1, NeedsGradingCountBySection: []needsGradingBySection{{SectionID: 211, NeedsGradingCount: 1}},
+		},
+	)
+
+	submittedAt := func(day int) null.String {
+		return null.StringFrom(time.Date(2026, 2, day, 9, 0, 0, 0, time.UTC).Format(time.RFC3339))
+	}
+
+	fixtures.Submissions = []canvas.Submission{
+		{ID: 1, UserID: 1000, AssignmentID: 300, WorkflowState: "submitted", SubmittedAt: submittedAt(3)},
+		{ID: 2, UserID: 1001, AssignmentID: 300, WorkflowState: "pending_review", SubmittedAt: submittedAt(2)},
+		// unsubmitted work and work graded on paper never count as the oldest pending submission
+		{ID: 3, UserID: 1002, AssignmentID: 300, WorkflowState: "unsubmitted", SubmittedAt: submittedAt(1)},
+		{ID: 4, UserID: 1003, AssignmentID: 300, WorkflowState: "submitted"},
+		{ID: 5, UserID: 1004, AssignmentID: 300, WorkflowState: "submitted", SubmittedAt: submittedAt(5)},
+		{ID: 6, UserID: 1006, AssignmentID: 310, WorkflowState: "submitted", SubmittedAt: submittedAt(1)},
+		{ID: 7, UserID: 1007, AssignmentID: 310, WorkflowState: "submitted", SubmittedAt: submittedAt(4)},
+		{ID: 8, UserID: 1008, AssignmentID: 310, WorkflowState: "submitted"},
+		{ID: 9, UserID: 1008, AssignmentID: 311, WorkflowState: "submitted"},
+	}
+
+	_, router := newTestServer(t, fixtures, nil, WithReportConcurrency(2))
+
+	coTaught := []string{
+		"310/211: courses/101/gradebook/speed_grader?assignment_id=310&student_id=1007",
+		"311/211: courses/101/gradebook/speed_grader?assignment_id=311",
+	}
+
+	// want the items of every teacher, oldest pending submission first and items without one last, with their SpeedGrader links
+	want := []struct {
+		teacherID    int
+		needsGrading int
+		courses      []string
+		items        []string
+	}{
+		{
+			teacherID:    10,
+			needsGrading: 3,
+			courses:      []string{"Math", "Optics"},
+			items: []string{
+				"310/210: courses/101/gradebook/speed_grader?assignment_id=310&student_id=1006",
+				"300/200: courses/100/gradebook/speed_grader?assignment_id=300&student_id=1001",
+			},
+		},
+		{teacherID: 11, needsGrading: 3, courses: []string{"Optics"}, items: coTaught},
+		{teacherID: 12, needsGrading: 3, courses: []string{"Optics"}, items: coTaught},
+		{
+			teacherID:    0,
+			needsGrading: 1,
+			courses:      []string{"Math"},
+			items:        []string{"300/201: courses/100/gradebook/speed_grader?assignment_id=300&student_id=1004"},
+		},
+	}
+
+	var results []TeacherBacklog
+
+	getJSON(t, router, "/accounts/1/teacher-backlog", &results)
+
+	if len(results) != len(want) {
+		t.Fatalf("got %d teachers, want %d", len(results), len(want))
+	}
+
+	for i, backlog := range results {
+		if backlog.TeacherID != want[i].teacherID || backlog.NeedsGrading != want[i].needsGrading || !slices.Equal(backlog.Courses, want[i].courses) {
+			t.Errorf("got teacher %d needing %d grades in %v, want teacher %d needing %d grades in %v",
+				backlog.TeacherID, backlog.NeedsGrading, backlog.Courses, want[i].teacherID, want[i].needsGrading, want[i].courses)
+		}
+
+		items := make([]string, 0, len(backlog.Items))
+
+		for _, item := range backlog.Items {
+			_, link, _ := strings.Cut(item.SpeedGraderUrl, "/courses/")
+			items = append(items, fmt.Sprintf("%d/%d: courses/%s", item.AssignmentID, item.SectionID, link))
+		}
+
+		if !slices.Equal(items, want[i].items) {
+			t.Errorf("got items of teacher %d %v, want %v", backlog.TeacherID, items, want[i].items)
+		}
+	}
+
+	if oldest := results[0].OldestSubmittedAt; !oldest.Valid || oldest.Time.Day() != 1 {
+		t.Errorf("got oldest submission %v of teacher 10, want the Lab submission of February 1", oldest)
+	}
+
+	getJSON(t, router, "/accounts/1/teacher-backlog?teacher=grace", &results)
+
+	if len(results) != 1 || results[0].TeacherName != "Grace Hopper" {
+		t.Errorf("got %d teachers, want Grace Hopper only", len(results))
+	}
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"canvas-report/canvas"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/guregu/null/v5"
+)
+
+type DiscrepancyType string
+
+const (
+	OverMaximumDiscrepancy        DiscrepancyType = "over_maximum"         // score above points possible
+	NegativeScoreDiscrepancy      DiscrepancyType = "negative_score"       // score below zero
+	ZeroPointsPossibleDiscrepancy DiscrepancyType = "zero_points_possible" // scored assignment worth no points
+	ExcusedScoredDiscrepancy      DiscrepancyType = "excused_scored"       // excused submission with a score
+)
+
+var discrepancyTypes = []DiscrepancyType{
+	OverMaximumDiscrepancy,
+	NegativeScoreDiscrepancy,
+	ZeroPointsPossibleDiscrepancy,
+	ExcusedScoredDiscrepancy,
+}
+
+// ScoreDiscrepancy is a submission score that is likely a grading mistake.
+type ScoreDiscrepancy struct {
+	Type           DiscrepancyType `json:"type"`
+	Account        string          `json:"account"`
+	CourseID       int             `json:"course_id"`
+	CourseName     string          `json:"course_name"`
+	AssignmentID   int             `json:"assignment_id"`
+	AssignmentName string          `json:"assignment_name"`
+	UserID         int             `json:"user_id"`
+	PointsPossible null.Float      `json:"points_possible"`
+	Score          null.Float      `json:"score"`
+	Grade          null.String     `json:"grade"`
+	SpeedGraderUrl string          `json:"speedgrader_url"`
+	ReportTerm
+}
+
+// GetScoreDiscrepanciesByAccountID audits the scores of every course in the given account, including courses of sub-accounts,
+// for scores above points possible, negative scores, scores of assignments worth no points and scores of excused submissions.
+// Results can be filtered by "term", "term_date" and "type" (comma separated discrepancy types).
+func (c *APIController) GetScoreDiscrepanciesByAccountID(w http.ResponseWriter, r *http.Request) {
+	accountID, err := strconv.Atoi(chi.URLParam(r, "account_id"))
+	if err != nil || accountID <= 0 {
+		http.Error(w, "account not found", http.StatusNotFound)
+		return
+	}
+
+	terms, err := parseTermFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	types := discrepancyTypes
+
+	if value := r.URL.Query().Get("type"); value != "" {
+		types = nil
+
+		for _, name := range strings.Split(value, ",") {
+			t := DiscrepancyType(strings.TrimSpace(name))
+			if !slices.Contains(discrepancyTypes, t) {
+				http.Error(w, "invalid type", http.StatusBadRequest)
+				return
+			}
+
+			types = append(types, t)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	client := canvasClientFromContext(ctx)
+
+	courses, err := coursesOfAccount(ctx, client, accountID, terms)
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching courses of account: %d", accountID))
+		return
+	}
+
+	resultsByCourse := make([][]*ScoreDiscrepancy, len(courses))
+
+	err = forEach(ctx, c.reportConcurrency, len(courses), func(ctx context.Context, i int) error {
+		course := courses[i]
+
+		// every state, since excused submissions need not be graded
+		for submission, err := range client.IterSubmissionsByCourseID(ctx, course.ID, 0, "") {
+			if canvas.IsNotFound(err) {
+				return nil // deleted since it was listed
+			}
+
+			if err != nil {
+				return err
+			}
+
+			t, ok := scoreDiscrepancyOf(submission)
+			if !ok || !slices.Contains(types, t) {
+				continue
+			}
+
+			resultsByCourse[i] = append(resultsByCourse[i], &ScoreDiscrepancy{
+				Type:           t,
+				Account:        course.Account.Name,
+				CourseID:       course.ID,
+				CourseName:     course.Name,
+				AssignmentID:   submission.AssignmentID,
+				AssignmentName: submission.Assignment.Name,
+				UserID:         submission.UserID,
+				PointsPossible: submission.Assignment.PointsPossible,
+				Score:          submission.Score,
+				Grade:          submission.Grade,
+				SpeedGraderUrl: fmt.Sprintf("%s/courses/%d/gradebook/speed_grader?assignment_id=%d&student_id=%d",
+					client.WebUrl, course.ID, submission.AssignmentID, submission.UserID),
+				ReportTerm: newReportTerm(course.Term),
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching submissions of account: %d", accountID))
+		return
+	}
+
+	results := slices.Concat(resultsByCourse...)
+
+	if results == nil {
+		results = make([]*ScoreDiscrepancy, 0)
+	}
+
+	if err := json.NewEncoder(w).Encode(&results); err != nil {
+		http.Error(w, "error encoding json response", http.StatusInternalServerError)
+	}
+}
+
+// scoreDiscrepancyOf returns the discrepancy of a scored submission, if any.
+func scoreDiscrepancyOf(submission *canvas.Submission) (DiscrepancyType, bool) {
+	if !submission.Score.Valid {
+		return "", false
+	}
+
+	pointsPossible := submission.Assignment.PointsPossible
+
+	switch {
+	case submission.Excused.Bool:
+		return ExcusedScoredDiscrepancy, true
+	case submission.Score.Float64 < 0:
+		return NegativeScoreDiscrepancy, true
+	case !pointsPossible.Valid || pointsPossible.Float64 == 0:
+		return ZeroPointsPossibleDiscrepancy, true
+	case submission.Score.Float64 > pointsPossible.Float64:
+		return OverMaximumDiscrepancy, true
+	}
+
+	return "", false
+}
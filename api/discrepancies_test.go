@@ -0,0 +1,136 @@
+package api
+
+import (
+	"canvas-report/canvas"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/guregu/null/v5"
+)
+
+func TestScoreDiscrepancyOf(t *testing.T) {
+	tests := []struct {
+		name           string
+		score          null.Float
+		pointsPossible null.Float
+		excused        bool
+		want           DiscrepancyType
+	}{
+		{name: "unscored", pointsPossible: null.FloatFrom(10)},
+		{name: "unscored excused", pointsPossible: null.FloatFrom(10), excused: true},
+		{name: "unscored without points possible"},
+		{name: "zero", score: null.FloatFrom(0), pointsPossible: null.FloatFrom(10)},
+		{name: "full marks", score: null.FloatFrom(10), pointsPossible: null.FloatFrom(10)},
+		{name: "over maximum", score: null.FloatFrom(10.5), pointsPossible: null.FloatFrom(10), want: OverMaximumDiscrepancy},
+		{name: "negative", score: null.FloatFrom(-1), pointsPossible: null.FloatFrom(10), want: NegativeScoreDiscrepancy},
+		{name: "zero points possible", score: null.FloatFrom(0), pointsPossible: null.FloatFrom(0), want: ZeroPointsPossibleDiscrepancy},
+		{name: "without points possible", score: null.FloatFrom(5), want: ZeroPointsPossibleDiscrepancy},
+		{name: "excused", score: null.FloatFrom(5), pointsPossible: null.FloatFrom(10), excused: true, want: ExcusedScoredDiscrepancy},
+
+		// a submission matching several discrepancies is reported once, excused first, then negative, then zero points possible
+		{name: "excused negative", score: null.FloatFrom(-1), pointsPossible: null.FloatFrom(10), excused: true, want: ExcusedScoredDiscrepancy},
+		{name: "excused over maximum", score: null.FloatFrom(12), pointsPossible: null.FloatFrom(10), excused: true, want: ExcusedScoredDiscrepancy},
+		{name: "excused zero points possible", score: null.FloatFrom(1), pointsPossible: null.FloatFrom(0), excused: true, want: ExcusedScoredDiscrepancy},
+		{name: "negative zero points possible", score: null.FloatFrom(-1), pointsPossible: null.FloatFrom(0), want: NegativeScoreDiscrepancy},
+		{name: "negative without points possible", score: null.FloatFrom(-1), want: NegativeScoreDiscrepancy},
+		{name: "over zero points possible", score: null.FloatFrom(5), pointsPossible: null.FloatFrom(0), want: ZeroPointsPossibleDiscrepancy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			submission := &canvas.Submission{Score: tt.score, Excused: null.BoolFrom(tt.excused)}
+			submission.Assignment.PointsPossible = tt.pointsPossible
+
+			got, ok := scoreDiscrepancyOf(submission)
+
+			if got != tt.want || ok != (tt.want != "") {
+				t.Errorf("got discrepancy %q, %v, want %q", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetScoreDiscrepanciesByAccountID(t *testing.T) {
+	fixtures := testFixtures()
+
+	// Optics of the Physics sub-account has an assignment worth no points
+	fixtures.Accounts = append(fixtures.Accounts, canvas.Account{ID: 2, Name: "Physics", ParentAccountID: null.IntFrom(1)})
+	fixtures.Courses = append(fixtures.Courses, canvas.Course{ID: 101, Name: "Optics", AccountID: 2, WorkflowState: "available"})
+	fixtures.Sections = append(fixtures.Sections, canvas.Section{ID: 210, CourseID: 101, Name: "Section O"})
+	fixtures.Enrollments = append(fixtures.Enrollments, canvas.Enrollment{
+		ID: 30, UserID: 1006, CourseID: 101, CourseSectionID: 210, Type: "StudentEnrollment", Role: "StudentEnrollment", EnrollmentState: "active",
+	})
+	fixtures.Assignments = append(fixtures.Assignments, canvas.Assignment{ID: 310, CourseID: 101, Name: "Survey", Published: true, PointsPossible: null.FloatFrom(0)})
+
+	fixtures.Submissions = []canvas.Submission{
+		{ID: 1, UserID: 1000, AssignmentID: 300, WorkflowState: "graded", Score: null.FloatFrom(8)},
+		{ID: 2, UserID: 1001, AssignmentID: 300, WorkflowState: "graded", Score: null.FloatFrom(12)},
+		{ID: 3, UserID: 1002, AssignmentID: 300, WorkflowState: "graded", Score: null.FloatFrom(-2)},
+		{ID: 4, UserID: 1003, AssignmentID: 300, WorkflowState: "unsubmitted", Score: null.FloatFrom(5), Excused: null.BoolFrom(true)},
+		{ID: 5, UserID: 1004, AssignmentID: 300, WorkflowState: "unsubmitted", Excused: null.BoolFrom(true)},
+		{ID: 6, UserID: 1006, AssignmentID: 310, WorkflowState: "graded", Score: null.FloatFrom(1)},
+	}
+
+	_, router := newTestServer(t, fixtures, nil, WithReportConcurrency(2))
+
+	tests := []struct {
+		url  string
+		want []string
+	}{
+		{
+			url:  "/accounts/1/score-discrepancies",
+			want: []string{"excused_scored 1003", "negative_score 1002", "over_maximum 1001", "zero_points_possible 1006"},
+		},
+		{url: "/accounts/2/score-discrepancies", want: []string{"zero_points_possible 1006"}},
+		{url: "/accounts/1/score-discrepancies?type=over_maximum", want: []string{"over_maximum 1001"}},
+		{
+			url:  "/accounts/1/score-discrepancies?type=negative_score,%20excused_scored",
+			want: []string{"excused_scored 1003", "negative_score 1002"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			var results []ScoreDiscrepancy
+
+			getJSON(t, router, tt.url, &results)
+
+			got := make([]string, 0, len(results))
+
+			for _, result := range results {
+				got = append(got, fmt.Sprintf("%s %d", result.Type, result.UserID))
+
+				link := fmt.Sprintf("/courses/%d/gradebook/speed_grader?assignment_id=%d&student_id=%d", result.CourseID, result.AssignmentID, result.UserID)
+
+				if !strings.HasSuffix(result.SpeedGraderUrl, link) {
+					t.Errorf("got SpeedGrader link %q, want one ending with %q", result.SpeedGraderUrl, link)
+				}
+			}
+
+			slices.Sort(got)
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got discrepancies %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	var results []ScoreDiscrepancy
+
+	getJSON(t, router, "/accounts/1/score-discrepancies?type=zero_points_possible", &results)
+
+	if len(results) != 1 || results[0].Account != "Physics" || results[0].CourseName != "Optics" || results[0].AssignmentName != "Survey" || results[0].PointsPossible != null.FloatFrom(0) {
+		t.Errorf("got %+v, want the Survey of Optics in Physics worth no points", results)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/accounts/1/score-discrepancies?type=over_maximum,typo", nil))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("got status %d with an invalid type, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,260 @@
+package api
+
+import (
+	"canvas-report/canvas"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/guregu/null/v5"
+)
+
+const (
+	defaultPassMark         = 50 // percentage of points possible
+	defaultHistogramBuckets = 10
+)
+
+// HistogramBucket counts scores from From, inclusive, to To, exclusive, in percentage of points possible.
+// The last bucket includes To, and scores above it.
+type HistogramBucket struct {
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Count int     `json:"count"`
+}
+
+// ScoreStats summarise graded scores in percentage of points possible.
+type ScoreStats struct {
+	Graded    int               `json:"graded"`
+	Mean      float64           `json:"mean"`
+	Median    float64           `json:"median"`
+	StdDev    float64           `json:"std_dev"`
+	Min       float64           `json:"min"`
+	Max       float64           `json:"max"`
+	PassRate  float64           `json:"pass_rate"` // share of scores at or above the pass mark, from 0 to 1
+	Histogram []HistogramBucket `json:"histogram"`
+}
+
+type SectionDistribution struct {
+	SectionID   int    `json:"section_id"`
+	SectionName string `json:"section_name"`
+	ScoreStats
+}
+
+type AssignmentDistribution struct {
+	AssignmentID   int        `json:"assignment_id"`
+	Name           string     `json:"name"`
+	PointsPossible null.Float `json:"points_possible"`
+	ScoreStats
+	Sections []SectionDistribution `json:"sections"`
+}
+
+// GetGradeDistributionByCourseID retrieves the distribution of graded scores of each assignment in the given course,
+// overall and per section, in percentage of points possible. Excused submissions and assignments without points possible are left out.
+// "pass_mark" sets the passing percentage, and "buckets" the number of histogram buckets.
+// Nothing is returned when the course does not match the "term" and "term_date" filters.
+func (c *APIController) GetGradeDistributionByCourseID(w http.ResponseWriter, r *http.Request) {
+	courseID, err := strconv.Atoi(chi.URLParam(r, "course_id"))
+	if err != nil || courseID <= 0 {
+		http.Error(w, "course not found", http.StatusNotFound)
+		return
+	}
+
+	params := r.URL.Query()
+
+	passMark := float64(defaultPassMark)
+
+	if value := params.Get("pass_mark"); value != "" {
+		passMark, err = strconv.ParseFloat(value, 64)
+		if err != nil || passMark < 0 {
+			http.Error(w, "invalid pass_mark", http.StatusBadRequest)
+			return
+		}
+	}
+
+	buckets := defaultHistogramBuckets
+
+	if value := params.Get("buckets"); value != "" {
+		buckets, err = strconv.Atoi(value)
+		if err != nil || buckets < 1 || buckets > 100 {
+			http.Error(w, "invalid buckets", http.StatusBadRequest)
+			return
+		}
+	}
+
+	terms, err := parseTermFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	client := canvasClientFromContext(ctx)
+
+	course, err := client.GetCourseByID(ctx, courseID)
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching course: %d", courseID))
+		return
+	}
+
+	results := make([]*AssignmentDistribution, 0)
+
+	if !terms.matches(course.Term) {
+		if err := json.NewEncoder(w).Encode(&results); err != nil {
+			http.Error(w, "error encoding json response", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	sections, err := client.GetSectionsByCourseID(ctx, courseID)
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching sections of course: %d", courseID))
+		return
+	}
+
+	sectionIDsByUserID := make(map[int][]int)
+
+	for _, section := range sections {
+		states := []canvas.EnrollmentState{canvas.ActiveEnrollmentState, canvas.InactiveEnrollmentState, canvas.CompletedEnrollmentState}
+		types := []canvas.EnrollmentType{canvas.StudentEnrollmentType}
+
+		for enrollment, err := range client.IterEnrollmentsBySectionID(ctx, section.ID, states, types) {
+			if err != nil {
+				writeCanvasError(w, err, fmt.Sprintf("error fetching enrollments of section: %d", section.ID))
+				return
+			}
+
+			sectionIDsByUserID[enrollment.UserID] = append(sectionIDsByUserID[enrollment.UserID], section.ID)
+		}
+	}
+
+	submissions, err := client.GetSubmissionsByCourseID(ctx, courseID, 0, canvas.GradedSubmissionWorkflowState)
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching graded submissions of course: %d", courseID))
+		return
+	}
+
+	resultByAssignmentID := make(map[int]*AssignmentDistribution)
+
+	// scores in percentage by assignment ID, overall and by section ID
+	scoresByAssignmentID := make(map[int][]float64)
+	sectionScoresByAssignmentID := make(map[int]map[int][]float64)
+
+	for _, submission := range submissions {
+		pointsPossible := submission.Assignment.PointsPossible
+
+		if !submission.Score.Valid || submission.Excused.Bool || !pointsPossible.Valid || pointsPossible.Float64 <= 0 {
+			continue
+		}
+
+		if _, ok := resultByAssignmentID[submission.AssignmentID]; !ok {
+			result := &AssignmentDistribution{
+				AssignmentID:   submission.AssignmentID,
+				Name:           submission.Assignment.Name,
+				PointsPossible: pointsPossible,
+				Sections:       []SectionDistribution{},
+			}
+
+			resultByAssignmentID[submission.AssignmentID] = result
+			sectionScoresByAssignmentID[submission.AssignmentID] = make(map[int][]float64)
+			results = append(results, result)
+		}
+
+		score := submission.Score.Float64 / pointsPossible.Float64 * 100
+
+		scoresByAssignmentID[submission.AssignmentID] = append(scoresByAssignmentID[submission.AssignmentID], score)
+
+		for _, sectionID := range sectionIDsByUserID[submission.UserID] {
+			sectionScoresByAssignmentID[submission.AssignmentID][sectionID] = append(sectionScoresByAssignmentID[submission.AssignmentID][sectionID], score)
+		}
+	}
+
+	for _, result := range results {
+		result.ScoreStats = newScoreStats(scoresByAssignmentID[result.AssignmentID], passMark, buckets)
+
+		for _, section := range sections {
+			scores, ok := sectionScoresByAssignmentID[result.AssignmentID][section.ID]
+			if !ok {
+				continue
+			}
+
+			result.Sections = append(result.Sections, SectionDistribution{
+				SectionID:   section.ID,
+				SectionName: section.Name,
+				ScoreStats:  newScoreStats(scores, passMark, buckets),
+			})
+		}
+	}
+
+	if err := json.NewEncoder(w).Encode(&results); err != nil {
+		http.Error(w, "error encoding json response", http.StatusInternalServerError)
+	}
+}
+
+// newScoreStats summarises scores in percentage, bucketing them from 0 to 100.
+func newScoreStats(scores []float64, passMark float64, buckets int) ScoreStats {
+	stats := ScoreStats{
+		Graded:    len(scores),
+		Histogram: make([]HistogramBucket, buckets),
+	}
+
+	width := 100 / float64(buckets)
+
+	for i := range stats.Histogram {
+		stats.Histogram[i].From = roundScore(float64(i) * width)
+		stats.Histogram[i].To = roundScore(float64(i+1) * width)
+	}
+
+	if len(scores) == 0 {
+		return stats
+	}
+
+	sorted := slices.Sorted(slices.Values(scores))
+
+	var sum float64
+	passed := 0
+
+	for _, score := range sorted {
+		sum += score
+
+		if score >= passMark {
+			passed++
+		}
+
+		bucket := min(max(int(score/width), 0), buckets-1)
+		stats.Histogram[bucket].Count++
+	}
+
+	mean := sum / float64(len(sorted))
+
+	var squares float64
+
+	for _, score := range sorted {
+		squares += (score - mean) * (score - mean)
+	}
+
+	median := sorted[len(sorted)/2]
+	if len(sorted)%2 == 0 {
+		median = (sorted[len(sorted)/2-1] + median) / 2
+	}
+
+	stats.Mean = roundScore(mean)
+	stats.Median = roundScore(median)
+	stats.StdDev = roundScore(math.Sqrt(squares / float64(len(sorted))))
+	stats.Min = roundScore(sorted[0])
+	stats.Max = roundScore(sorted[len(sorted)-1])
+	stats.PassRate = math.Round(float64(passed)/float64(len(sorted))*1000) / 1000
+
+	return stats
+}
+
+// roundScore rounds a percentage to two decimal places.
+func roundScore(score float64) float64 {
+	return math.Round(score*100) / 100
+}
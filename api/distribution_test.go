@@ -0,0 +1,67 @@
+package api
+
+import (
+	"canvas-report/canvas"
+	"slices"
+	"testing"
+
+	"github.com/guregu/null/v5"
+)
+
+func TestGetGradeDistributionByCourseID(t *testing.T) {
+	fixtures := testFixtures()
+
+	// scores of students 1000 to 1003 in section 200 and 1004 in section 201, 1005 is not graded
+	for i, score := range []float64{2, 4, 6, 8, 10} {
+		fixtures.Submissions = append(fixtures.Submissions, canvas.Submission{
+			ID:            i + 1,
+			UserID:        1000 + i,
+			AssignmentID:  300,
+			WorkflowState: string(canvas.GradedSubmissionWorkflowState),
+			Score:         null.FloatFrom(score),
+		})
+	}
+
+	fixtures.Submissions = append(fixtures.Submissions, canvas.Submission{ID: 6, UserID: 1005, AssignmentID: 300, WorkflowState: "unsubmitted"})
+
+	_, router := newTestServer(t, fixtures, nil)
+
+	var results []AssignmentDistribution
+
+	getJSON(t, router, "/courses/100/grade-distribution?buckets=5", &results)
+
+	if len(results) != 1 {
+		t.Fatalf("got %d assignments, want 1", len(results))
+	}
+
+	result := results[0]
+
+	if result.AssignmentID != 300 || result.Name != "Homework" {
+		t.Errorf("got assignment %d %q, want 300 Homework", result.AssignmentID, result.Name)
+	}
+
+	if result.Graded != 5 || result.Mean != 60 || result.Median != 60 || result.Min != 20 || result.Max != 100 || result.PassRate != 0.6 {
+		t.Errorf("got stats %+v, want 5 graded from 20 to 100, mean and median 60, pass rate 0.6", result.ScoreStats)
+	}
+
+	counts := make([]int, 0, len(result.Histogram))
+	for _, bucket := range result.Histogram {
+		counts = append(counts, bucket.Count)
+	}
+
+	if want := []int{0, 1, 1, 1, 2}; !slices.Equal(counts, want) {
+		t.Errorf("got histogram %v, want %v", counts, want)
+	}
+
+	if len(result.Sections) != 2 {
+		t.Fatalf("got %d sections, want 2", len(result.Sections))
+	}
+
+	if section := result.Sections[0]; section.SectionID != 200 || section.Graded != 4 || section.Mean != 50 {
+		t.Errorf("got section %d with %d graded and mean %v, want 200 with 4 graded and mean 50", section.SectionID, section.Graded, section.Mean)
+	}
+
+	if section := result.Sections[1]; section.SectionID != 201 || section.Graded != 1 || section.Mean != 100 {
+		t.Errorf("got section %d with %d graded and mean %v, want 201 with 1 graded and mean 100", section.SectionID, section.Graded, section.Mean)
+	}
+}
@@ -24,10 +24,12 @@ type EnrollmentResult struct {
 	CurrentScore    null.Float  `json:"current_score"`
 	EnrollmentRole  string      `json:"enrollment_role"`
 	GradesURL       string      `json:"grades_url"`
+	ReportTerm
 }
 
 // GetStudentEnrollmentsResultByUserID returns enrollments result of given user ID.
 // Only student enrollments of the user is retrieved.
+// Results can be filtered by "term" and "term_date", and grouped by term with "group_by=term".
 func (c *APIController) GetStudentEnrollmentsResultByUserID(w http.ResponseWriter, r *http.Request) {
 	userIDParam := chi.URLParam(r, "user_id")
 	if userIDParam == "" {
@@ -43,22 +45,30 @@ func (c *APIController) GetStudentEnrollmentsResultByUserID(w http.ResponseWrite
 
 	states := canvas.GetOnlyValidEnrollmentState(r.URL.Query()["state[]"])
 
-	ctx, cancel := context.WithCancel(r.Context())
-	defer cancel()
+	terms, err := parseTermFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	enrollments, code, err := c.canvasClient.GetEnrollmentsByUserID(ctx, userID, states)
+	grouped, err := parseGroupByTerm(r)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("error fetching enrollments of user: %d", userID), code)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	courses, code, err := c.canvasClient.GetCoursesByUserID(ctx, userID)
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	client := canvasClientFromContext(ctx)
+
+	courses, err := client.GetCoursesByUserID(ctx, userID)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("error fetching courses of user: %d", userID), code)
+		writeCanvasError(w, err, fmt.Sprintf("error fetching courses of user: %d", userID))
 		return
 	}
 
-	results := make([]*EnrollmentResult, len(enrollments))
+	results := make([]*EnrollmentResult, 0)
 
 	courseByCourseID := make(map[int]*canvas.Course, len(courses))
 
@@ -66,7 +76,29 @@ func (c *APIController) GetStudentEnrollmentsResultByUserID(w http.ResponseWrite
 		courseByCourseID[course.ID] = course
 	}
 
-	for i, enrollment := range enrollments {
+	// enrollments are processed as pages arrive rather than buffered up front
+	for enrollment, err := range client.IterEnrollmentsByUserID(ctx, userID, states) {
+		if err != nil {
+			writeCanvasError(w, err, fmt.Sprintf("error fetching enrollments of user: %d", userID))
+			return
+		}
+
+		course, ok := courseByCourseID[enrollment.CourseID]
+		if !ok {
+			fetched, err := client.GetCourseByID(ctx, enrollment.CourseID)
+			if err != nil {
+				writeCanvasError(w, err, fmt.Sprintf("error fetching course: %d", enrollment.CourseID))
+				return
+			}
+
+			course = &fetched
+			courseByCourseID[enrollment.CourseID] = course
+		}
+
+		if !terms.matches(course.Term) {
+			continue
+		}
+
 		result := &EnrollmentResult{
 			SISUserID:       enrollment.User.SISUserID,
 			StudentName:     enrollment.User.Name,
@@ -76,38 +108,27 @@ func (c *APIController) GetStudentEnrollmentsResultByUserID(w http.ResponseWrite
 			EnrollmentState: enrollment.EnrollmentState,
 			EnrollmentRole:  enrollment.Role,
 			SectionName:     enrollment.SISSectionID.String,
-		}
-
-		if course, ok := courseByCourseID[enrollment.CourseID]; ok {
-			result.CourseName = course.Name
-			result.CourseState = course.WorkflowState
-			result.AccountName = course.Account.Name
-
-		} else {
-			course, code, err := c.canvasClient.GetCourseByID(enrollment.CourseID)
-			if err != nil {
-				http.Error(w, fmt.Sprintf("error fetching course: %d", enrollment.CourseID), code)
-				return
-			}
-
-			courseByCourseID[enrollment.CourseID] = &course
-
-			result.CourseName = course.Name
-			result.CourseState = course.WorkflowState
-			result.AccountName = course.Account.Name
+			CourseName:      course.Name,
+			CourseState:     course.WorkflowState,
+			AccountName:     course.Account.Name,
+			ReportTerm:      newReportTerm(course.Term),
 		}
 
 		if result.SectionName == "" {
-			section, code, err := c.canvasClient.GetSectionByID(enrollment.CourseSectionID)
+			section, err := client.GetSectionByID(ctx, enrollment.CourseSectionID)
 			if err != nil {
-				http.Error(w, fmt.Sprintf("error fetching section: %d", enrollment.CourseSectionID), code)
+				writeCanvasError(w, err, fmt.Sprintf("error fetching section: %d", enrollment.CourseSectionID))
 				return
 			}
 
 			result.SectionName = section.Name
 		}
 
-		results[i] = result
+		results = append(results, result)
+	}
+
+	if grouped {
+		groupByTerm(results)
 	}
 
 	if err := json.NewEncoder(w).Encode(&results); err != nil {
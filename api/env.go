@@ -0,0 +1,208 @@
+package api
+
+import (
+	"canvas-report/canvas"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewAPIControllerFromEnv creates the controller configured by environment variables, see README.md.
+// It is shared by the server and the lambda, getenv is usually os.Getenv.
+func NewAPIControllerFromEnv(getenv func(string) string) (*APIController, error) {
+	// JSON array of named Canvas instances served under /tenants/{name}.
+	// The instance of CANVAS_BASE_URL is optional when there are tenants.
+	tenantsEnv := getenv("CANVAS_TENANTS")
+
+	canvasBaseUrl := getenv("CANVAS_BASE_URL")
+	if canvasBaseUrl == "" && tenantsEnv == "" {
+		return nil, fmt.Errorf("missing env: CANVAS_BASE_URL")
+	}
+
+	// An OAuth2 developer key with a refresh token can be used instead of the access token.
+	canvasAccessToken := getenv("CANVAS_ACCESS_TOKEN")
+	canvasRefreshToken := getenv("CANVAS_OAUTH2_REFRESH_TOKEN")
+	if canvasBaseUrl != "" && canvasAccessToken == "" && canvasRefreshToken == "" {
+		return nil, fmt.Errorf("missing env: CANVAS_ACCESS_TOKEN")
+	}
+
+	// Number of items to fetch per request when paginating with the Canvas API.
+	pageSize := 100
+
+	if value := getenv("CANVAS_PAGE_SIZE"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("invalid env: CANVAS_PAGE_SIZE")
+		}
+
+		pageSize = size
+	}
+
+	clientOptions, err := clientOptionsFromEnv(getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	var canvasClient *canvas.CanvasClient
+
+	if canvasBaseUrl != "" {
+		config := TenantConfig{
+			Name:         "default",
+			BaseUrl:      canvasBaseUrl,
+			AccessToken:  canvasAccessToken,
+			ClientID:     getenv("CANVAS_OAUTH2_CLIENT_ID"),
+			ClientSecret: getenv("CANVAS_OAUTH2_CLIENT_SECRET"),
+			RefreshToken: canvasRefreshToken,
+		}
+
+		canvasClient, err = NewTenantClient(config, pageSize, clientOptions...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	controllerOptions := []ControllerOption{}
+
+	if tenantsEnv != "" {
+		tenants, err := ParseTenantConfigs(tenantsEnv)
+		if err != nil {
+			return nil, fmt.Errorf("invalid env: CANVAS_TENANTS: %w", err)
+		}
+
+		for _, tenant := range tenants {
+			client, err := NewTenantClient(tenant, pageSize, clientOptions...)
+			if err != nil {
+				return nil, err
+			}
+
+			controllerOptions = append(controllerOptions, WithTenant(tenant.Name, client))
+		}
+	}
+
+	if getenv("CANVAS_USE_GRAPHQL") == "true" {
+		controllerOptions = append(controllerOptions, WithGraphQLReports())
+	}
+
+	// Number of courses fetched at once by account-wide reports.
+	if value := getenv("CANVAS_REPORT_CONCURRENCY"); value != "" {
+		concurrency, err := strconv.Atoi(value)
+		if err != nil || concurrency <= 0 {
+			return nil, fmt.Errorf("invalid env: CANVAS_REPORT_CONCURRENCY")
+		}
+
+		controllerOptions = append(controllerOptions, WithReportConcurrency(concurrency))
+	}
+
+	// Days teachers have to grade a submission in grading turnaround reports.
+	if value := getenv("CANVAS_GRADING_SLA_DAYS"); value != "" {
+		days, err := strconv.ParseFloat(value, 64)
+		if err != nil || days <= 0 {
+			return nil, fmt.Errorf("invalid env: CANVAS_GRADING_SLA_DAYS")
+		}
+
+		controllerOptions = append(controllerOptions, WithGradingSLA(time.Duration(days*float64(24*time.Hour))))
+	}
+
+	// Weights of the at-risk students report per account.
+	if value := getenv("CANVAS_RISK_CONFIGS"); value != "" {
+		riskConfigs, err := ParseRiskConfigs(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid env: CANVAS_RISK_CONFIGS: %w", err)
+		}
+
+		controllerOptions = append(controllerOptions, WithRiskConfigs(riskConfigs))
+	}
+
+	// Run reports with the caller's Canvas token from the X-Canvas-Token header instead of the configured one.
+	// Sessions are only available to applications embedding the controller.
+	if getenv("CANVAS_TOKEN_PASSTHROUGH") == "true" {
+		controllerOptions = append(controllerOptions, WithTokenPassthrough(nil))
+	}
+
+	// Comma separated JWT subjects allowed to view reports as another Canvas user with "as_user_id".
+	if value := getenv("CANVAS_MASQUERADE_OPERATORS"); value != "" {
+		operators := strings.Split(value, ",")
+		audit := log.New(os.Stderr, "audit: ", log.LstdFlags)
+
+		controllerOptions = append(controllerOptions, WithMasquerade(operators, audit))
+	}
+
+	var auther *Auther
+
+	if jwtSecret := getenv("JWT_SECRET"); jwtSecret != "" {
+		auther, err = NewAuther(jwtSecret, getenv("JWT_ISSUER"))
+		if err != nil {
+			return nil, fmt.Errorf("error creating auther: %w", err)
+		}
+	}
+
+	return NewAPIController(canvasClient, auther, controllerOptions...)
+}
+
+// clientOptionsFromEnv returns the options of the Canvas clients of every tenant: retries, caching and recording.
+func clientOptionsFromEnv(getenv func(string) string) ([]canvas.ClientOption, error) {
+	retryPolicy := canvas.DefaultRetryPolicy
+
+	if value := getenv("CANVAS_MAX_RETRIES"); value != "" {
+		maxRetries, err := strconv.Atoi(value)
+		if err != nil || maxRetries < 0 {
+			return nil, fmt.Errorf("invalid env: CANVAS_MAX_RETRIES")
+		}
+
+		retryPolicy.MaxRetries = maxRetries
+	}
+
+	clientOptions := []canvas.ClientOption{canvas.WithRetryPolicy(retryPolicy)}
+
+	cacheSize := 1000
+
+	if value := getenv("CANVAS_CACHE_SIZE"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid env: CANVAS_CACHE_SIZE")
+		}
+
+		cacheSize = size
+	}
+
+	switch getenv("CANVAS_CACHE") {
+	case "":
+	case "memory":
+		cache, err := canvas.NewLRUCache(cacheSize)
+		if err != nil {
+			return nil, fmt.Errorf("error creating canvas cache: %w", err)
+		}
+
+		clientOptions = append(clientOptions, canvas.WithCache(cache, nil))
+	case "disk":
+		cacheDir := getenv("CANVAS_CACHE_DIR")
+		if cacheDir == "" {
+			cacheDir = filepath.Join(os.TempDir(), "canvas-report-cache")
+		}
+
+		cache, err := canvas.NewDiskCache(cacheDir, cacheSize)
+		if err != nil {
+			return nil, fmt.Errorf("error creating canvas cache: %w", err)
+		}
+
+		clientOptions = append(clientOptions, canvas.WithCache(cache, nil))
+	default:
+		return nil, fmt.Errorf("invalid env: CANVAS_CACHE")
+	}
+
+	// Record Canvas interactions, scrubbed of tokens and personal data, to replay them offline.
+	if recordDir := getenv("CANVAS_RECORD_DIR"); recordDir != "" {
+		recorder, err := canvas.NewRecorder(recordDir)
+		if err != nil {
+			return nil, fmt.Errorf("error creating canvas recorder: %w", err)
+		}
+
+		clientOptions = append(clientOptions, canvas.WithRecorder(recorder))
+	}
+
+	return clientOptions, nil
+}
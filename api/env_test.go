@@ -0,0 +1,84 @@
+package api
+
+import "testing"
+
+func TestNewAPIControllerFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{
+			name:    "missing base url",
+			env:     map[string]string{},
+			wantErr: "missing env: CANVAS_BASE_URL",
+		},
+		{
+			name:    "missing access token",
+			env:     map[string]string{"CANVAS_BASE_URL": "https://canvas.test/api/v1"},
+			wantErr: "missing env: CANVAS_ACCESS_TOKEN",
+		},
+		{
+			name:    "invalid retries",
+			env:     map[string]string{"CANVAS_BASE_URL": "https://canvas.test/api/v1", "CANVAS_ACCESS_TOKEN": "token", "CANVAS_MAX_RETRIES": "-1"},
+			wantErr: "invalid env: CANVAS_MAX_RETRIES",
+		},
+		{
+			name:    "invalid cache",
+			env:     map[string]string{"CANVAS_BASE_URL": "https://canvas.test/api/v1", "CANVAS_ACCESS_TOKEN": "token", "CANVAS_CACHE": "redis"},
+			wantErr: "invalid env: CANVAS_CACHE",
+		},
+		{
+			name:    "invalid grading sla",
+			env:     map[string]string{"CANVAS_BASE_URL": "https://canvas.test/api/v1", "CANVAS_ACCESS_TOKEN": "token", "CANVAS_GRADING_SLA_DAYS": "0"},
+			wantErr: "invalid env: CANVAS_GRADING_SLA_DAYS",
+		},
+		{
+			name:    "masquerade without auther",
+			env:     map[string]string{"CANVAS_BASE_URL": "https://canvas.test/api/v1", "CANVAS_ACCESS_TOKEN": "token", "CANVAS_MASQUERADE_OPERATORS": "admin"},
+			wantErr: "masquerade operators need an auther",
+		},
+		{
+			name: "default instance",
+			env: map[string]string{
+				"CANVAS_BASE_URL":           "https://canvas.test/api/v1",
+				"CANVAS_ACCESS_TOKEN":       "token",
+				"CANVAS_CACHE":              "memory",
+				"CANVAS_REPORT_CONCURRENCY": "2",
+				"CANVAS_USE_GRAPHQL":        "true",
+			},
+		},
+		{
+			name: "tenants only",
+			env: map[string]string{
+				"CANVAS_TENANTS": `[{"name":"prod","base_url":"https://canvas.test/api/v1","access_token":"token"}]`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller, err := NewAPIControllerFromEnv(func(key string) string { return tt.env[key] })
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("got error %v, want %q", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.env["CANVAS_USE_GRAPHQL"] == "true" && (!controller.useGraphQL || controller.reportConcurrency != 2) {
+				t.Errorf("got controller %+v, want GraphQL reports with a concurrency of 2", controller)
+			}
+
+			if tt.env["CANVAS_TENANTS"] != "" && (controller.canvasClient != nil || controller.tenants["prod"] == nil) {
+				t.Errorf("got controller %+v, want only the prod tenant", controller)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"canvas-report/canvas"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// writeCanvasError responds with the HTTP status matching the failed Canvas request.
+// Message describes what was being fetched and is followed by the Canvas error messages, if any.
+func writeCanvasError(w http.ResponseWriter, err error, message string) {
+	status := http.StatusBadGateway
+
+	var canvasErr *canvas.Error
+	isCanvasErr := errors.As(err, &canvasErr)
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		status = http.StatusRequestTimeout
+	case errors.Is(err, context.DeadlineExceeded):
+		status = http.StatusGatewayTimeout
+	case errors.Is(err, canvas.ErrInvalidGrant):
+		// the service's own Canvas credentials were rejected, the caller is not at fault
+		status = http.StatusBadGateway
+	case canvas.IsNotFound(err):
+		status = http.StatusNotFound
+	case canvas.IsUnauthorized(err):
+		status = http.StatusUnauthorized
+	case canvas.IsRateLimited(err):
+		status = http.StatusTooManyRequests
+	case canvas.IsForbidden(err):
+		status = http.StatusForbidden
+	case isCanvasErr && canvasErr.StatusCode == http.StatusBadRequest:
+		status = http.StatusBadRequest
+	}
+
+	if isCanvasErr {
+		log.Printf("%s: %v (url: %s, canvas request id: %s)\n", message, err, canvasErr.URL, canvasErr.RequestID)
+
+		if len(canvasErr.Messages) > 0 {
+			message = fmt.Sprintf("%s: %s", message, strings.Join(canvasErr.Messages, "; "))
+		}
+	} else {
+		log.Printf("%s: %v\n", message, err)
+	}
+
+	http.Error(w, message, status)
+}
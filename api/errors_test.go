@@ -0,0 +1,79 @@
+package api
+
+import (
+	"canvas-report/canvas"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteCanvasErrorStatuses(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		rateLimit  bool
+		wantStatus int
+	}{
+		{name: "bad request", status: http.StatusBadRequest, wantStatus: http.StatusBadRequest},
+		{name: "unauthorized", status: http.StatusUnauthorized, wantStatus: http.StatusUnauthorized},
+		{name: "forbidden", status: http.StatusForbidden, wantStatus: http.StatusForbidden},
+		{name: "not found", status: http.StatusNotFound, wantStatus: http.StatusNotFound},
+		{name: "too many requests", status: http.StatusTooManyRequests, wantStatus: http.StatusTooManyRequests},
+		{name: "server error", status: http.StatusInternalServerError, wantStatus: http.StatusBadGateway},
+		{name: "rate limit exceeded", rateLimit: true, wantStatus: http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientOpts := []canvas.ClientOption{canvas.WithRetryPolicy(canvas.RetryPolicy{}), canvas.WithRateLimitThreshold(0)}
+
+			srv, router := newTestServer(t, testFixtures(), clientOpts)
+
+			if tt.rateLimit {
+				srv.SetRateLimit(0, 1)
+			} else {
+				srv.InjectError("/courses/100", tt.status, 1)
+			}
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/courses/100/grade-distribution", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("got status %d, want %d", rec.Code, tt.wantStatus)
+			}
+
+			if !tt.rateLimit && !strings.Contains(rec.Body.String(), "injected error") {
+				t.Errorf("got body %q, want the Canvas error message", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestWriteCanvasErrorContext(t *testing.T) {
+	tests := []struct {
+		err        error
+		wantStatus int
+	}{
+		{err: &canvas.Error{Resource: "course: 1", Err: context.Canceled}, wantStatus: http.StatusRequestTimeout},
+		{err: &canvas.Error{Resource: "course: 1", Err: context.DeadlineExceeded}, wantStatus: http.StatusGatewayTimeout},
+		{err: &canvas.Error{Resource: "course: 1", Err: errors.New("connection refused")}, wantStatus: http.StatusBadGateway},
+		{err: fmt.Errorf("error fetching courses: %w", &canvas.Error{StatusCode: http.StatusNotFound}), wantStatus: http.StatusNotFound},
+		{err: &canvas.Error{Resource: "course: 1", Err: fmt.Errorf("%w: invalid_grant", canvas.ErrInvalidGrant)}, wantStatus: http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			rec := httptest.NewRecorder()
+
+			writeCanvasError(rec, tt.err, "error fetching course: 1")
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("got status %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"canvas-report/canvas"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/guregu/null/v5"
+)
+
+func TestStudentAssignmentsResultWithGraphQLMatchesREST(t *testing.T) {
+	fixtures := testFixtures()
+
+	fixtures.Terms = []canvas.Term{
+		{ID: 1, Name: "Fall", StartAt: null.TimeFrom(time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC))},
+		{ID: 2, Name: "Spring", StartAt: null.TimeFrom(time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC))},
+	}
+
+	fixtures.Courses[0].EnrollmentTermID = 2
+	fixtures.Sections[0].SISSectionID = null.StringFrom("SEC-A")
+	fixtures.Enrollments[1].SISSectionID = null.StringFrom("SEC-A")
+
+	// course 101 of another term, and course 102 where student 1000 is only invited
+	fixtures.Courses = append(fixtures.Courses,
+		canvas.Course{ID: 101, Name: "Biology", AccountID: 1, WorkflowState: "available", EnrollmentTermID: 1},
+		canvas.Course{ID: 102, Name: "Chemistry", AccountID: 1, WorkflowState: "available", EnrollmentTermID: 1},
+	)
+	fixtures.Sections = append(fixtures.Sections,
+		canvas.Section{ID: 202, CourseID: 101, Name: "Section C"},
+		canvas.Section{ID: 203, CourseID: 102, Name: "Section D"},
+	)
+	fixtures.Enrollments = append(fixtures.Enrollments,
+		canvas.Enrollment{ID: 20, UserID: 1000, CourseID: 101, CourseSectionID: 202, Type: "StudentEnrollment", Role: "StudentEnrollment", EnrollmentState: "completed"},
+		canvas.Enrollment{ID: 21, UserID: 1000, CourseID: 102, CourseSectionID: 203, Type: "StudentEnrollment", Role: "StudentEnrollment", EnrollmentState: "invited"},
+	)
+
+	dueAt := time.Date(2026, 2, 1, 23, 59, 0, 0, time.UTC)
+
+	// more submissions in course 100 than fit in a page of the submissions connection
+	for i := range 12 {
+		assignmentID := 310 + i
+
+		fixtures.Assignments = append(fixtures.Assignments, canvas.Assignment{
+			ID: assignmentID, CourseID: 100, Name: fmt.Sprintf("Quiz %d", i+1), Published: true,
+			PointsPossible: null.FloatFrom(5), DueAt: null.TimeFrom(dueAt.AddDate(0, 0, i)),
+		})
+
+		submission := canvas.Submission{ID: assignmentID, UserID: 1000, AssignmentID: assignmentID, WorkflowState: "graded", Score: null.FloatFrom(4)}
+
+		switch i % 3 {
+		case 0:
+			submission.SubmittedAt = null.StringFrom(dueAt.Format(time.RFC3339))
+		case 1:
+			submission.SubmittedAt = null.StringFrom(dueAt.AddDate(0, 0, 20).Format(time.RFC3339))
+			submission.Late = true
+		case 2:
+			submission.WorkflowState = "unsubmitted"
+			submission.Score = null.Float{}
+			submission.Missing = true
+		}
+
+		fixtures.Submissions = append(fixtures.Submissions, submission)
+	}
+
+	fixtures.Assignments = append(fixtures.Assignments,
+		canvas.Assignment{ID: 400, CourseID: 101, Name: "Lab", Published: true, PointsPossible: null.FloatFrom(10)},
+		canvas.Assignment{ID: 401, CourseID: 102, Name: "Essay", Published: true, PointsPossible: null.FloatFrom(10)},
+	)
+
+	fixtures.Submissions = append(fixtures.Submissions,
+		canvas.Submission{ID: 1, UserID: 1000, AssignmentID: 300, WorkflowState: "graded", Score: null.FloatFrom(9), SubmittedAt: null.StringFrom(dueAt.Format(time.RFC3339))},
+		// more points than possible is reported as a discrepancy
+		canvas.Submission{ID: 2, UserID: 1000, AssignmentID: 400, WorkflowState: "graded", Score: null.FloatFrom(12), SubmittedAt: null.StringFrom(dueAt.Format(time.RFC3339))},
+		canvas.Submission{ID: 3, UserID: 1000, AssignmentID: 401, WorkflowState: "unsubmitted", Missing: true},
+	)
+
+	_, rest := newTestServer(t, fixtures, nil)
+	srv, graphql := newTestServer(t, fixtures, nil, WithGraphQLReports())
+
+	for _, query := range []string{"", "?term=Spring", "?group_by=term"} {
+		t.Run(query, func(t *testing.T) {
+			url := "/users/1000/student-assignments-result" + query
+
+			want := getBody(t, rest, url)
+
+			before := srv.Requests()
+			got := getBody(t, graphql, url)
+
+			if got != want {
+				t.Errorf("got GraphQL report\n%s\nwant the REST report\n%s", got, want)
+			}
+
+			// the student report, then the second page of submissions of course 100
+			if got := srv.Requests() - before; got != 2 {
+				t.Errorf("got %d GraphQL requests, want 2", got)
+			}
+		})
+	}
+
+	var results []AssignmentResult
+
+	getJSON(t, graphql, "/users/1000/student-assignments-result", &results)
+
+	if len(results) != 14 {
+		t.Errorf("got %d results, want every submission of the available enrollments", len(results))
+	}
+}
+
+// getBody serves a GET request and returns the response body, failing unless it responds with 200 OK.
+func getBody(t *testing.T, router http.Handler, url string) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s responded with %d: %s", url, rec.Code, rec.Body.String())
+	}
+
+	return rec.Body.String()
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"canvas-report/canvas"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// WithMasquerade lets the given JWT subjects view reports as another Canvas user with "as_user_id=<user id>".
+// Every masqueraded request is recorded in audit. Subjects are trimmed and empty ones ignored.
+// Masquerading needs an auther, since operators are identified by JWT subject.
+func WithMasquerade(operators []string, audit *log.Logger) ControllerOption {
+	return func(c *APIController) {
+		c.masqueradeOperators = nil
+
+		for _, operator := range operators {
+			if operator = strings.TrimSpace(operator); operator != "" {
+				c.masqueradeOperators = append(c.masqueradeOperators, operator)
+			}
+		}
+
+		c.audit = audit
+	}
+}
+
+// withMasquerade is a middleware that makes the Canvas requests of the report as the user of "as_user_id".
+// It responds with 403 Forbidden unless the caller is an operator.
+func (c *APIController) withMasquerade(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		param := r.URL.Query().Get("as_user_id")
+		if param == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		subject := subjectFromContext(r.Context())
+
+		if subject == "" || !slices.Contains(c.masqueradeOperators, subject) {
+			http.Error(w, "masquerading not allowed", http.StatusForbidden)
+			return
+		}
+
+		userID, err := strconv.Atoi(param)
+		if err != nil || userID <= 0 {
+			http.Error(w, "invalid as_user_id", http.StatusBadRequest)
+			return
+		}
+
+		if c.audit != nil {
+			c.audit.Printf("masquerade: subject=%q as_user_id=%d tenant=%q request=%q request_id=%s\n",
+				subject, userID, chi.URLParam(r, "tenant"), r.Method+" "+r.URL.Path, middleware.GetReqID(r.Context()))
+		}
+
+		next.ServeHTTP(w, r.WithContext(canvas.WithMasquerade(r.Context(), userID)))
+	}
+
+	return http.HandlerFunc(fn)
+}
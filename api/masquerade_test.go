@@ -0,0 +1,78 @@
+package api
+
+import (
+	"canvas-report/canvas/canvastest"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMasquerade(t *testing.T) {
+	srv := canvastest.NewServer(testFixtures())
+	t.Cleanup(srv.Close)
+
+	client, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auther, err := NewAuther("secret", "canvas-report")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// as split from CANVAS_MASQUERADE_OPERATORS=" admin, ,ops"
+	operators := []string{" admin", " ", "ops"}
+
+	controller, err := NewAPIController(client, auther, WithMasquerade(operators, log.New(io.Discard, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := NewRouter(controller, nil)
+
+	tests := []struct {
+		subject    string
+		wantStatus int
+	}{
+		{subject: "admin", wantStatus: http.StatusOK},
+		{subject: "ops", wantStatus: http.StatusOK},
+		{subject: "teacher", wantStatus: http.StatusForbidden},
+		{subject: "", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.subject, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/courses/100/grade-distribution?as_user_id=10", nil)
+			req.Header.Set("Authorization", "Bearer "+signedJWT(t, "secret", tt.subject))
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("got status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestMasqueradeNeedsAuther(t *testing.T) {
+	srv := canvastest.NewServer(testFixtures())
+	t.Cleanup(srv.Close)
+
+	client, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewAPIController(client, nil, WithMasquerade([]string{"admin"}, nil)); err == nil {
+		t.Error("got no error for masquerade operators without an auther")
+	}
+
+	// empty operators do not enable masquerading
+	if _, err := NewAPIController(client, nil, WithMasquerade([]string{" ", ""}, nil)); err != nil {
+		t.Errorf("got error %v without masquerade operators", err)
+	}
+}
@@ -0,0 +1,366 @@
+package api
+
+import (
+	"canvas-report/canvas"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/guregu/null/v5"
+)
+
+// MissingAssignment is an assignment past its due date that the student has not submitted,
+// or that Canvas or the teacher marked missing.
+type MissingAssignment struct {
+	AssignmentID int       `json:"assignment_id"`
+	Name         string    `json:"name"`
+	DueAt        null.Time `json:"due_at"` // due date of the section of the student, null when marked missing without one
+	DaysOverdue  int       `json:"days_overdue"`
+	HtmlUrl      string    `json:"html_url"`
+}
+
+// MissingSubmissionsResult is the missing work of a student in a section.
+type MissingSubmissionsResult struct {
+	UserID           int                 `json:"user_id"`
+	UserSisID        string              `json:"user_sis_id"`
+	UserName         string              `json:"user_name"`
+	CourseID         int                 `json:"course_id"`
+	CourseName       string              `json:"course_name"`
+	SectionID        int                 `json:"section_id"`
+	SectionName      string              `json:"section_name"`
+	Missing          []MissingAssignment `json:"missing"`
+	OtherOutstanding int                 `json:"other_outstanding"` // missing assignments of the student in other courses
+}
+
+// MissingSubmissionsRollup is the missing work of a student across the courses of an account.
+type MissingSubmissionsRollup struct {
+	UserID         int                         `json:"user_id"`
+	UserSisID      string                      `json:"user_sis_id"`
+	UserName       string                      `json:"user_name"`
+	Missing        int                         `json:"missing"`
+	MaxDaysOverdue int                         `json:"max_days_overdue"`
+	Courses        []*MissingSubmissionsResult `json:"courses"`
+}
+
+// GetMissingSubmissionsByCourseID retrieves the active students of the given course with work past due and not submitted,
+// or marked missing, one result per section, with due dates of the section. Other outstanding work counts the missing assignments
+// of the student in their other courses. Nothing is returned when the course does not match the "term" and "term_date" filters.
+func (c *APIController) GetMissingSubmissionsByCourseID(w http.ResponseWriter, r *http.Request) {
+	courseID, err := strconv.Atoi(chi.URLParam(r, "course_id"))
+	if err != nil || courseID <= 0 {
+		http.Error(w, "course not found", http.StatusNotFound)
+		return
+	}
+
+	terms, err := parseTermFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	client := canvasClientFromContext(ctx)
+
+	course, err := client.GetCourseByID(ctx, courseID)
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching course: %d", courseID))
+		return
+	}
+
+	if !terms.matches(course.Term) {
+		if err := json.NewEncoder(w).Encode(make([]*MissingSubmissionsResult, 0)); err != nil {
+			http.Error(w, "error encoding json response", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	results, err := missingSubmissionsOfCourse(ctx, client, &course, time.Now())
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching missing submissions of course: %d", courseID))
+		return
+	}
+
+	var userIDs []int
+
+	for _, result := range results {
+		if !slices.Contains(userIDs, result.UserID) {
+			userIDs = append(userIDs, result.UserID)
+		}
+	}
+
+	var mu sync.Mutex
+	otherOutstandingByUserID := make(map[int]int)
+
+	err = forEach(ctx, c.reportConcurrency, len(userIDs), func(ctx context.Context, i int) error {
+		otherOutstanding := 0
+
+		for assignment, err := range client.IterMissingSubmissionsByUserID(ctx, userIDs[i]) {
+			if err != nil {
+				return err
+			}
+
+			if assignment.CourseID != course.ID {
+				otherOutstanding++
+			}
+		}
+
+		mu.Lock()
+		otherOutstandingByUserID[userIDs[i]] = otherOutstanding
+		mu.Unlock()
+
+		return nil
+	})
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching other missing submissions of students of course: %d", courseID))
+		return
+	}
+
+	for _, result := range results {
+		result.OtherOutstanding = otherOutstandingByUserID[result.UserID]
+	}
+
+	if err := json.NewEncoder(w).Encode(&results); err != nil {
+		http.Error(w, "error encoding json response", http.StatusInternalServerError)
+	}
+}
+
+// GetMissingSubmissionsByAccountID retrieves the students with work past due and not submitted, or marked missing, in the courses
+// of the given account, including courses of sub-accounts, students with the most missing assignments first.
+// Other outstanding work counts the missing assignments of the student in the other courses of the account.
+// Results can be filtered by "term" and "term_date".
+func (c *APIController) GetMissingSubmissionsByAccountID(w http.ResponseWriter, r *http.Request) {
+	accountID, err := strconv.Atoi(chi.URLParam(r, "account_id"))
+	if err != nil || accountID <= 0 {
+		http.Error(w, "account not found", http.StatusNotFound)
+		return
+	}
+
+	terms, err := parseTermFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	client := canvasClientFromContext(ctx)
+
+	courses, err := coursesOfAccount(ctx, client, accountID, terms)
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching courses of account: %d", accountID))
+		return
+	}
+
+	now := time.Now()
+	resultsByCourse := make([][]*MissingSubmissionsResult, len(courses))
+
+	err = forEach(ctx, c.reportConcurrency, len(courses), func(ctx context.Context, i int) error {
+		results, err := missingSubmissionsOfCourse(ctx, client, courses[i], now)
+		if canvas.IsNotFound(err) {
+			return nil // deleted since it was listed
+		}
+
+		if err != nil {
+			return err
+		}
+
+		resultsByCourse[i] = results
+
+		return nil
+	})
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching missing submissions of account: %d", accountID))
+		return
+	}
+
+	rollups := make([]*MissingSubmissionsRollup, 0)
+	rollupByUserID := make(map[int]*MissingSubmissionsRollup)
+
+	// course ID by assignment ID of the missing work of each student, since a student can be in several sections of a course
+	missingByUserID := make(map[int]map[int]int)
+
+	for _, results := range resultsByCourse {
+		for _, result := range results {
+			rollup, ok := rollupByUserID[result.UserID]
+			if !ok {
+				rollup = &MissingSubmissionsRollup{UserID: result.UserID, UserSisID: result.UserSisID, UserName: result.UserName}
+				rollupByUserID[result.UserID] = rollup
+				missingByUserID[result.UserID] = make(map[int]int)
+				rollups = append(rollups, rollup)
+			}
+
+			rollup.Courses = append(rollup.Courses, result)
+
+			for _, missing := range result.Missing {
+				missingByUserID[result.UserID][missing.AssignmentID] = result.CourseID
+				rollup.MaxDaysOverdue = max(rollup.MaxDaysOverdue, missing.DaysOverdue)
+			}
+		}
+	}
+
+	for _, rollup := range rollups {
+		rollup.Missing = len(missingByUserID[rollup.UserID])
+
+		for _, result := range rollup.Courses {
+			for _, courseID := range missingByUserID[rollup.UserID] {
+				if courseID != result.CourseID {
+					result.OtherOutstanding++
+				}
+			}
+		}
+	}
+
+	slices.SortStableFunc(rollups, func(a, b *MissingSubmissionsRollup) int {
+		return cmp.Or(
+			cmp.Compare(b.Missing, a.Missing),
+			cmp.Compare(b.MaxDaysOverdue, a.MaxDaysOverdue),
+			cmp.Compare(a.UserName, b.UserName),
+		)
+	})
+
+	if err := json.NewEncoder(w).Encode(&rollups); err != nil {
+		http.Error(w, "error encoding json response", http.StatusInternalServerError)
+	}
+}
+
+// missingSubmissionsOfCourse returns the active students of the course with missing submissions,
+// see missingDueAt, of published assignments expecting a submission.
+// Assignments are not fetched with the "overdue" bucket, since Canvas applies the due dates of the caller to it.
+func missingSubmissionsOfCourse(ctx context.Context, client *canvas.CanvasClient, course *canvas.Course, now time.Time) ([]*MissingSubmissionsResult, error) {
+	// all dates are included along with the needs grading count by section
+	assignments, err := client.GetAssignmentsByCourseID(ctx, course.ID, "", canvas.AllAssignmentBucket, true)
+	if err != nil {
+		return nil, err
+	}
+
+	assignmentsByID := make(map[int]*canvas.Assignment)
+
+	for _, assignment := range assignments {
+		if assignment.Published && assignment.ExpectsSubmission() {
+			assignmentsByID[assignment.ID] = assignment
+		}
+	}
+
+	sections, err := client.GetSectionsByCourseID(ctx, course.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*MissingSubmissionsResult, 0)
+
+	for _, section := range sections {
+		if len(assignmentsByID) == 0 {
+			break
+		}
+
+		states := []canvas.EnrollmentState{canvas.ActiveEnrollmentState}
+		types := []canvas.EnrollmentType{canvas.StudentEnrollmentType}
+
+		enrollments, err := client.GetEnrollmentsBySectionID(ctx, section.ID, states, types)
+		if err != nil {
+			return nil, err
+		}
+
+		studentsByUserID := make(map[int]canvas.User)
+
+		for _, enrollment := range enrollments {
+			studentsByUserID[enrollment.UserID] = enrollment.User
+		}
+
+		resultByUserID := make(map[int]*MissingSubmissionsResult)
+
+		// every state, since submissions marked missing may be submitted or graded
+		for submission, err := range client.IterSubmissionsBySectionID(ctx, section.ID, "") {
+			if err != nil {
+				return nil, err
+			}
+
+			student, ok := studentsByUserID[submission.UserID]
+			if !ok {
+				continue
+			}
+
+			assignment, ok := assignmentsByID[submission.AssignmentID]
+			if !ok {
+				continue
+			}
+
+			dueAt, ok := missingDueAt(submission, assignment, section.ID, now)
+			if !ok {
+				continue
+			}
+
+			result, ok := resultByUserID[submission.UserID]
+			if !ok {
+				result = &MissingSubmissionsResult{
+					UserID:      submission.UserID,
+					UserSisID:   student.SISUserID,
+					UserName:    student.Name,
+					CourseID:    course.ID,
+					CourseName:  course.Name,
+					SectionID:   section.ID,
+					SectionName: section.Name,
+				}
+
+				resultByUserID[submission.UserID] = result
+				results = append(results, result)
+			}
+
+			missing := MissingAssignment{
+				AssignmentID: assignment.ID,
+				Name:         assignment.Name,
+				HtmlUrl:      assignment.HtmlUrl,
+			}
+
+			if !dueAt.IsZero() {
+				missing.DueAt = null.TimeFrom(dueAt)
+				missing.DaysOverdue = max(int(now.Sub(dueAt).Hours()/24), 0)
+			}
+
+			result.Missing = append(result.Missing, missing)
+		}
+	}
+
+	for _, result := range results {
+		slices.SortStableFunc(result.Missing, func(a, b MissingAssignment) int {
+			return compareOldest(a.DueAt, b.DueAt)
+		})
+	}
+
+	return results, nil
+}
+
+// missingDueAt returns the due date of the assignment for the section when the submission is missing at now,
+// like Canvas counts it: marked missing by Canvas or the teacher, or not submitted and past due.
+// Excused submissions and submissions whose late policy status the teacher set otherwise are never missing.
+// The due date is zero for submissions marked missing without one, or not yet past due.
+func missingDueAt(submission *canvas.Submission, assignment *canvas.Assignment, sectionID int, now time.Time) (time.Time, bool) {
+	if submission.Excused.Bool {
+		return time.Time{}, false
+	}
+
+	date, ok := assignment.DateOfSection(sectionID)
+	if !ok || date.DueAt.After(now) {
+		date.DueAt = time.Time{}
+	}
+
+	if submission.Missing || submission.LatePolicyStatus.String == "missing" {
+		return date.DueAt, true
+	}
+
+	if submission.LatePolicyStatus.Valid || submission.WorkflowState != string(canvas.UnsubmittedSubmissionWorkflowState) || date.DueAt.IsZero() {
+		return time.Time{}, false
+	}
+
+	return date.DueAt, true
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"canvas-report/canvas"
+	"testing"
+	"time"
+
+	"github.com/guregu/null/v5"
+)
+
+func TestGetMissingSubmissionsByCourseID(t *testing.T) {
+	fixtures := testFixtures()
+
+	dueAt := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+
+	fixtures.Assignments[0].SubmissionTypes = []string{"online_upload"}
+	fixtures.Assignments[0].AllDates = []canvas.AssignmentDate{{DueAt: dueAt, Base: true}}
+
+	// without a due date, so only missing when marked
+	fixtures.Assignments = append(fixtures.Assignments, canvas.Assignment{ID: 301, CourseID: 100, Name: "Project", Published: true, SubmissionTypes: []string{"online_upload"}})
+
+	fixtures.Submissions = []canvas.Submission{
+		{ID: 1, UserID: 1000, AssignmentID: 300, WorkflowState: "unsubmitted"},
+		{ID: 2, UserID: 1001, AssignmentID: 300, WorkflowState: "graded", Missing: true},
+		{ID: 3, UserID: 1002, AssignmentID: 300, WorkflowState: "submitted", LatePolicyStatus: null.StringFrom("missing")},
+		{ID: 4, UserID: 1003, AssignmentID: 300, WorkflowState: "unsubmitted", Excused: null.BoolFrom(true)},
+		{ID: 5, UserID: 1004, AssignmentID: 300, WorkflowState: "unsubmitted", LatePolicyStatus: null.StringFrom("extended")},
+		{ID: 6, UserID: 1005, AssignmentID: 300, WorkflowState: "submitted"},
+		{ID: 7, UserID: 1000, AssignmentID: 301, WorkflowState: "unsubmitted", Missing: true},
+		{ID: 8, UserID: 1001, AssignmentID: 301, WorkflowState: "unsubmitted"},
+	}
+
+	_, router := newTestServer(t, fixtures, nil)
+
+	var results []MissingSubmissionsResult
+
+	getJSON(t, router, "/courses/100/missing-submissions", &results)
+
+	missingByUserID := make(map[int][]MissingAssignment)
+
+	for _, result := range results {
+		missingByUserID[result.UserID] = result.Missing
+	}
+
+	want := map[int][]int{
+		1000: {300, 301},
+		1001: {300},
+		1002: {300},
+	}
+
+	if len(missingByUserID) != len(want) {
+		t.Errorf("got missing work of %d students, want %d", len(missingByUserID), len(want))
+	}
+
+	for userID, assignmentIDs := range want {
+		missing := missingByUserID[userID]
+
+		if len(missing) != len(assignmentIDs) {
+			t.Errorf("got %d missing assignments of user %d, want %d", len(missing), userID, len(assignmentIDs))
+			continue
+		}
+
+		for i, assignmentID := range assignmentIDs {
+			if missing[i].AssignmentID != assignmentID {
+				t.Errorf("got missing assignment %d of user %d, want %d", missing[i].AssignmentID, userID, assignmentID)
+			}
+		}
+
+		if !missing[0].DueAt.Valid || !missing[0].DueAt.Time.Equal(dueAt) || missing[0].DaysOverdue != 2 {
+			t.Errorf("got due date %v, %d days overdue, want %v, 2 days overdue", missing[0].DueAt, missing[0].DaysOverdue, dueAt)
+		}
+	}
+
+	if project := missingByUserID[1000][1]; project.DueAt.Valid || project.DaysOverdue != 0 {
+		t.Errorf("got due date %v, %d days overdue of a missing assignment without due date", project.DueAt, project.DaysOverdue)
+	}
+
+	var rollups []MissingSubmissionsRollup
+
+	getJSON(t, router, "/accounts/1/missing-submissions", &rollups)
+
+	if len(rollups) != 3 || rollups[0].UserID != 1000 || rollups[0].Missing != 2 {
+		t.Errorf("got rollups %+v, want user 1000 first with 2 missing assignments out of 3 students", rollups)
+	}
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// CanvasTokenHeader carries the caller's own Canvas access token when token passthrough is enabled.
+const CanvasTokenHeader = "X-Canvas-Token"
+
+// ErrNoSession is returned by SessionStore when the subject has no Canvas token.
+var ErrNoSession = errors.New("no canvas session")
+
+// SessionStore looks up the Canvas access token of a user authenticated by withAuth, by JWT subject.
+type SessionStore interface {
+	CanvasToken(ctx context.Context, subject string) (string, error)
+}
+
+// MemorySessionStore is an in-memory SessionStore.
+type MemorySessionStore struct {
+	mu     sync.RWMutex
+	tokens map[string]string
+}
+
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{tokens: make(map[string]string)}
+}
+
+// Set stores the Canvas token of the subject, replacing any previous one.
+func (s *MemorySessionStore) Set(subject, token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[subject] = token
+}
+
+// Delete removes the Canvas token of the subject, e.g. on logout.
+func (s *MemorySessionStore) Delete(subject string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.tokens, subject)
+}
+
+func (s *MemorySessionStore) CanvasToken(ctx context.Context, subject string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	token, ok := s.tokens[subject]
+	if !ok {
+		return "", ErrNoSession
+	}
+
+	return token, nil
+}
+
+// WithTokenPassthrough runs reports with the caller's own Canvas token instead of the configured one,
+// so callers only see what Canvas lets them see. The token is taken from the X-Canvas-Token header,
+// or from sessions by the JWT subject when the header is missing. Sessions can be nil.
+func WithTokenPassthrough(sessions SessionStore) ControllerOption {
+	return func(c *APIController) {
+		c.tokenPassthrough = true
+		c.sessions = sessions
+	}
+}
+
+// withCanvasToken is a middleware that replaces the tenant Canvas client with one using the caller's token.
+// Requests without a token are rejected rather than served with the configured token.
+func (c *APIController) withCanvasToken(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if !c.tokenPassthrough {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token := r.Header.Get(CanvasTokenHeader)
+
+		// the subject is only set for requests authenticated by withAuth
+		if subject := subjectFromContext(r.Context()); token == "" && subject != "" && c.sessions != nil {
+			sessionToken, err := c.sessions.CanvasToken(r.Context(), subject)
+			if err != nil && !errors.Is(err, ErrNoSession) {
+				http.Error(w, "error fetching canvas session", http.StatusInternalServerError)
+				return
+			}
+
+			token = sessionToken
+		}
+
+		if token == "" {
+			http.Error(w, "missing canvas token", http.StatusUnauthorized)
+			return
+		}
+
+		client, err := canvasClientFromContext(r.Context()).WithAccessToken(token)
+		if err != nil {
+			http.Error(w, "invalid canvas token", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), canvasClientKey{}, client)))
+	}
+
+	return http.HandlerFunc(fn)
+}
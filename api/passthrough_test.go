@@ -0,0 +1,95 @@
+package api
+
+import (
+	"canvas-report/canvas/canvastest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTokenPassthrough(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{name: "missing token", wantStatus: http.StatusUnauthorized},
+		{name: "token rejected by canvas", token: "invalid-token", wantStatus: http.StatusUnauthorized},
+		{name: "valid token", token: canvastest.Token, wantStatus: http.StatusOK},
+	}
+
+	_, router := newTestServer(t, testFixtures(), nil, WithTokenPassthrough(nil))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/courses/100/grade-distribution", nil)
+
+			if tt.token != "" {
+				req.Header.Set(CanvasTokenHeader, tt.token)
+			}
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("got status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestTokenPassthroughSessions(t *testing.T) {
+	srv := canvastest.NewServer(testFixtures())
+	t.Cleanup(srv.Close)
+
+	client, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auther, err := NewAuther("secret", "canvas-report")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sessions := NewMemorySessionStore()
+	sessions.Set("teacher", canvastest.Token)
+	sessions.Set("revoked", "revoked-token")
+
+	controller, err := NewAPIController(client, auther, WithTokenPassthrough(sessions))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := NewRouter(controller, nil)
+
+	tests := []struct {
+		name       string
+		subject    string
+		header     string
+		wantStatus int
+	}{
+		{name: "subject with session", subject: "teacher", wantStatus: http.StatusOK},
+		{name: "subject without session", subject: "student", wantStatus: http.StatusUnauthorized},
+		{name: "session token rejected by canvas", subject: "revoked", wantStatus: http.StatusUnauthorized},
+		{name: "header over session", subject: "revoked", header: canvastest.Token, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/courses/100/grade-distribution", nil)
+			req.Header.Set("Authorization", "Bearer "+signedJWT(t, "secret", tt.subject))
+
+			if tt.header != "" {
+				req.Header.Set(CanvasTokenHeader, tt.header)
+			}
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("got status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
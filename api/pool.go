@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"sync"
+)
+
+// defaultReportConcurrency is the number of courses fetched at once by account-wide reports.
+const defaultReportConcurrency = 4
+
+// WithReportConcurrency sets how many courses account-wide reports fetch at once.
+func WithReportConcurrency(concurrency int) ControllerOption {
+	return func(c *APIController) {
+		c.reportConcurrency = max(concurrency, 1)
+	}
+}
+
+// forEach calls fn for every index below n, running at most concurrency calls at once.
+// The first error cancels the context of the other calls and is returned.
+func forEach(ctx context.Context, concurrency, n int, fn func(ctx context.Context, i int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sem := make(chan struct{}, max(concurrency, 1))
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	for i := range n {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := fn(ctx, i); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	return ctx.Err()
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"canvas-report/canvas"
+	"net/http"
+	"strconv"
+)
+
+// rateLimitHeaderWriter sets the remaining Canvas quota header right before the response is written,
+// so the value reflects the Canvas calls made while handling the request.
+type rateLimitHeaderWriter struct {
+	http.ResponseWriter
+	client      *canvas.CanvasClient
+	wroteHeader bool
+}
+
+func (w *rateLimitHeaderWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+
+		limit := w.client.RateLimit()
+		if !limit.UpdatedAt.IsZero() {
+			w.Header().Set("X-Canvas-Rate-Limit-Remaining", strconv.FormatFloat(limit.Remaining, 'f', 2, 64))
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *rateLimitHeaderWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// withRateLimitHeader is a middleware that reports the remaining Canvas quota
+// of the tenant in the X-Canvas-Rate-Limit-Remaining response header.
+func withRateLimitHeader(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&rateLimitHeaderWriter{ResponseWriter: w, client: canvasClientFromContext(r.Context())}, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
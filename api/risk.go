@@ -0,0 +1,397 @@
+package api
+
+import (
+	"canvas-report/canvas"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/guregu/null/v5"
+)
+
+// RiskConfig holds the weights of the signals adding up to the risk score of a student in at-risk reports.
+type RiskConfig struct {
+	AccountID      int     `json:"account_id"`      // applies to courses of the account and its sub-accounts, 0 for every account
+	ScoreThreshold float64 `json:"score_threshold"` // current scores below this percentage add to the risk
+	ScoreWeight    float64 `json:"score_weight"`    // added for a current score of 0, proportionally less up to the threshold
+	MissingWeight  float64 `json:"missing_weight"`  // added per missing submission
+	LateWeight     float64 `json:"late_weight"`     // added per late submission
+	InactiveWeight float64 `json:"inactive_weight"` // added per inactive enrollment
+	MinRisk        float64 `json:"min_risk"`        // students with a lower risk score are left out
+}
+
+// defaultRiskConfig is used for accounts without a RiskConfig, unless one is given for account 0.
+var defaultRiskConfig = RiskConfig{
+	ScoreThreshold: 50,
+	ScoreWeight:    10,
+	MissingWeight:  2,
+	LateWeight:     1,
+	InactiveWeight: 5,
+}
+
+// ParseRiskConfigs decodes a JSON array of risk configs, e.g.
+//
+//	[{"account_id":0,"score_threshold":50,"score_weight":10,"missing_weight":2,"late_weight":1,"inactive_weight":5}]
+func ParseRiskConfigs(data string) ([]RiskConfig, error) {
+	var configs []RiskConfig
+
+	if err := json.Unmarshal([]byte(data), &configs); err != nil {
+		return nil, fmt.Errorf("error decoding risk configs: %w", err)
+	}
+
+	accountIDs := make(map[int]bool, len(configs))
+
+	for _, config := range configs {
+		if config.AccountID < 0 {
+			return nil, fmt.Errorf("invalid risk config account: %d", config.AccountID)
+		}
+
+		if accountIDs[config.AccountID] {
+			return nil, fmt.Errorf("duplicate risk config account: %d", config.AccountID)
+		}
+
+		accountIDs[config.AccountID] = true
+
+		weights := []float64{config.ScoreThreshold, config.ScoreWeight, config.MissingWeight, config.LateWeight, config.InactiveWeight, config.MinRisk}
+
+		if slices.ContainsFunc(weights, func(weight float64) bool { return weight < 0 }) {
+			return nil, fmt.Errorf("negative weight in risk config of account: %d", config.AccountID)
+		}
+	}
+
+	return configs, nil
+}
+
+// WithRiskConfigs sets the weights of at-risk reports per account.
+func WithRiskConfigs(configs []RiskConfig) ControllerOption {
+	return func(c *APIController) {
+		c.riskConfigs = make(map[int]RiskConfig, len(configs))
+
+		for _, config := range configs {
+			c.riskConfigs[config.AccountID] = config
+		}
+	}
+}
+
+// riskConfigResolver resolves the risk configs of the accounts in a report, caching them by account ID.
+type riskConfigResolver struct {
+	controller *APIController
+	path       []canvas.Account    // from the root account down to the account of the report
+	tree       *canvas.AccountTree // the account of the report and its sub-accounts, nil without configs
+	configs    map[int]RiskConfig
+}
+
+// newRiskConfigResolver fetches the accounts needed to resolve the risk configs of the given account and its sub-accounts.
+func (c *APIController) newRiskConfigResolver(ctx context.Context, client *canvas.CanvasClient, accountID int) (*riskConfigResolver, error) {
+	path, err := client.GetAccountPath(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	resolver := &riskConfigResolver{
+		controller: c,
+		path:       path,
+		configs:    make(map[int]RiskConfig),
+	}
+
+	// sub-accounts only matter when they may have configs of their own
+	if len(c.riskConfigs) > 0 {
+		resolver.tree, err = client.GetAccountTree(ctx, accountID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resolver, nil
+}
+
+// of returns the config of the given account. Accounts outside the tree get the config of the account of the report.
+func (r *riskConfigResolver) of(accountID int) RiskConfig {
+	if config, ok := r.configs[accountID]; ok {
+		return config
+	}
+
+	path := r.path
+
+	if r.tree != nil {
+		// the tree path starts at the account of the report, which ends r.path
+		if sub := r.tree.Path(accountID); len(sub) > 1 {
+			path = append(slices.Clone(r.path), sub[1:]...)
+		}
+	}
+
+	config := r.controller.riskConfigOf(path)
+	r.configs[accountID] = config
+
+	return config
+}
+
+// riskConfigOf returns the config of the innermost account of the path having one.
+func (c *APIController) riskConfigOf(path []canvas.Account) RiskConfig {
+	for i := len(path) - 1; i >= 0; i-- {
+		if config, ok := c.riskConfigs[path[i].ID]; ok {
+			return config
+		}
+	}
+
+	if config, ok := c.riskConfigs[0]; ok {
+		return config
+	}
+
+	return defaultRiskConfig
+}
+
+// CourseRisk are the risk signals of a student in a course.
+type CourseRisk struct {
+	CourseID        int        `json:"course_id"`
+	CourseName      string     `json:"course_name"`
+	CurrentScore    null.Float `json:"current_score"`
+	Missing         int        `json:"missing"`
+	Late            int        `json:"late"`
+	EnrollmentState string     `json:"enrollment_state"`
+	Risk            float64    `json:"risk"`
+}
+
+// StudentRisk is the risk score of a student across their courses.
+type StudentRisk struct {
+	UserID    int           `json:"user_id"`
+	UserSisID string        `json:"user_sis_id"`
+	UserName  string        `json:"user_name"`
+	Risk      float64       `json:"risk"`
+	Missing   int           `json:"missing"`
+	Late      int           `json:"late"`
+	Inactive  int           `json:"inactive"` // inactive enrollments
+	Courses   []*CourseRisk `json:"courses"`
+}
+
+// GetAtRiskStudentsByAccountID ranks the students of the courses in the given account, including courses of sub-accounts,
+// by a risk score combining low current scores, missing and late submissions and inactive enrollments.
+// Signals of each course are weighted by the risk config of the course account,
+// and students are left out below the minimum risk of the requested account.
+// Results can be filtered by "term" and "term_date".
+func (c *APIController) GetAtRiskStudentsByAccountID(w http.ResponseWriter, r *http.Request) {
+	accountID, err := strconv.Atoi(chi.URLParam(r, "account_id"))
+	if err != nil || accountID <= 0 {
+		http.Error(w, "account not found", http.StatusNotFound)
+		return
+	}
+
+	terms, err := parseTermFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	client := canvasClientFromContext(ctx)
+
+	configs, err := c.newRiskConfigResolver(ctx, client, accountID)
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching accounts of account: %d", accountID))
+		return
+	}
+
+	courses, err := coursesOfAccount(ctx, client, accountID, terms)
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching courses of account: %d", accountID))
+		return
+	}
+
+	now := time.Now()
+	signalsByCourse := make([][]*studentCourseRisk, len(courses))
+
+	err = forEach(ctx, c.reportConcurrency, len(courses), func(ctx context.Context, i int) error {
+		signals, err := riskSignalsOfCourse(ctx, client, courses[i], now)
+		if canvas.IsNotFound(err) {
+			return nil // deleted since it was listed
+		}
+
+		if err != nil {
+			return err
+		}
+
+		signalsByCourse[i] = signals
+
+		return nil
+	})
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching risk signals of account: %d", accountID))
+		return
+	}
+
+	results := make([]*StudentRisk, 0)
+	resultByUserID := make(map[int]*StudentRisk)
+
+	for i, signals := range signalsByCourse {
+		config := configs.of(courses[i].AccountID)
+
+		for _, signal := range signals {
+			result, ok := resultByUserID[signal.user.ID]
+			if !ok {
+				result = &StudentRisk{UserID: signal.user.ID, UserSisID: signal.user.SISUserID, UserName: signal.user.Name}
+				resultByUserID[signal.user.ID] = result
+				results = append(results, result)
+			}
+
+			signal.Risk = config.risk(signal.CourseRisk)
+
+			result.Risk += signal.Risk
+			result.Missing += signal.Missing
+			result.Late += signal.Late
+			result.Courses = append(result.Courses, &signal.CourseRisk)
+
+			if signal.EnrollmentState == string(canvas.InactiveEnrollmentState) {
+				result.Inactive++
+			}
+		}
+	}
+
+	minRisk := configs.of(accountID).MinRisk
+
+	results = slices.DeleteFunc(results, func(result *StudentRisk) bool {
+		result.Risk = math.Round(result.Risk*100) / 100
+
+		return result.Risk == 0 || result.Risk < minRisk
+	})
+
+	for _, result := range results {
+		slices.SortStableFunc(result.Courses, func(a, b *CourseRisk) int {
+			return cmp.Compare(b.Risk, a.Risk)
+		})
+	}
+
+	slices.SortStableFunc(results, func(a, b *StudentRisk) int {
+		return cmp.Or(cmp.Compare(b.Risk, a.Risk), cmp.Compare(a.UserName, b.UserName))
+	})
+
+	if err := json.NewEncoder(w).Encode(&results); err != nil {
+		http.Error(w, "error encoding json response", http.StatusInternalServerError)
+	}
+}
+
+// risk returns the weighted risk score of the signals of a course.
+func (config RiskConfig) risk(signals CourseRisk) float64 {
+	risk := float64(signals.Missing)*config.MissingWeight + float64(signals.Late)*config.LateWeight
+
+	if signals.CurrentScore.Valid && config.ScoreThreshold > 0 && signals.CurrentScore.Float64 < config.ScoreThreshold {
+		risk += config.ScoreWeight * (config.ScoreThreshold - max(signals.CurrentScore.Float64, 0)) / config.ScoreThreshold
+	}
+
+	if signals.EnrollmentState == string(canvas.InactiveEnrollmentState) {
+		risk += config.InactiveWeight
+	}
+
+	return math.Round(risk*100) / 100
+}
+
+type studentCourseRisk struct {
+	CourseRisk
+	user canvas.User
+}
+
+// riskSignalsOfCourse returns the risk signals of the active and inactive students of the course.
+// Missing submissions are counted like missingSubmissionsOfCourse does.
+func riskSignalsOfCourse(ctx context.Context, client *canvas.CanvasClient, course *canvas.Course, now time.Time) ([]*studentCourseRisk, error) {
+	// all dates are included along with the needs grading count by section
+	assignments, err := client.GetAssignmentsByCourseID(ctx, course.ID, "", canvas.AllAssignmentBucket, true)
+	if err != nil {
+		return nil, err
+	}
+
+	assignmentsByID := make(map[int]*canvas.Assignment)
+
+	for _, assignment := range assignments {
+		if assignment.Published && assignment.ExpectsSubmission() {
+			assignmentsByID[assignment.ID] = assignment
+		}
+	}
+
+	sections, err := client.GetSectionsByCourseID(ctx, course.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*studentCourseRisk, 0)
+	resultByUserID := make(map[int]*studentCourseRisk)
+
+	// a student enrolled in several sections has their submissions listed in each
+	counted := make(map[int]bool)
+
+	for _, section := range sections {
+		states := []canvas.EnrollmentState{canvas.ActiveEnrollmentState, canvas.InactiveEnrollmentState}
+		types := []canvas.EnrollmentType{canvas.StudentEnrollmentType}
+
+		enrollments, err := client.GetEnrollmentsBySectionID(ctx, section.ID, states, types)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, enrollment := range enrollments {
+			if result, ok := resultByUserID[enrollment.UserID]; ok {
+				// active in any section is active in the course
+				if enrollment.EnrollmentState == string(canvas.ActiveEnrollmentState) {
+					result.EnrollmentState = enrollment.EnrollmentState
+				}
+
+				continue
+			}
+
+			user := enrollment.User
+			user.ID = enrollment.UserID
+
+			result := &studentCourseRisk{
+				CourseRisk: CourseRisk{
+					CourseID:        course.ID,
+					CourseName:      course.Name,
+					CurrentScore:    enrollment.Grades.CurrentScore,
+					EnrollmentState: enrollment.EnrollmentState,
+				},
+				user: user,
+			}
+
+			resultByUserID[enrollment.UserID] = result
+			results = append(results, result)
+		}
+
+		if len(assignmentsByID) == 0 {
+			continue
+		}
+
+		for submission, err := range client.IterSubmissionsBySectionID(ctx, section.ID, "") {
+			if err != nil {
+				return nil, err
+			}
+
+			result, ok := resultByUserID[submission.UserID]
+			if !ok || counted[submission.ID] {
+				continue
+			}
+
+			assignment, ok := assignmentsByID[submission.AssignmentID]
+			if !ok {
+				continue
+			}
+
+			counted[submission.ID] = true
+
+			if submission.Late {
+				result.Late++
+			}
+
+			if _, ok := missingDueAt(submission, assignment, section.ID, now); ok {
+				result.Missing++
+			}
+		}
+	}
+
+	return results, nil
+}
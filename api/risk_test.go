@@ -0,0 +1,68 @@
+package api
+
+import (
+	"canvas-report/canvas"
+	"testing"
+	"time"
+
+	"github.com/guregu/null/v5"
+)
+
+func TestGetAtRiskStudentsByAccountIDWeightsCoursesByAccount(t *testing.T) {
+	fixtures := testFixtures()
+
+	dueAt := time.Now().Add(-48 * time.Hour)
+
+	// course 101 of sub-account 2, with student 1000 enrolled in both courses
+	fixtures.Accounts = append(fixtures.Accounts, canvas.Account{ID: 2, Name: "Physics", ParentAccountID: null.IntFrom(1)})
+	fixtures.Courses = append(fixtures.Courses, canvas.Course{ID: 101, Name: "Mechanics", AccountID: 2, WorkflowState: "available"})
+	fixtures.Sections = append(fixtures.Sections, canvas.Section{ID: 202, CourseID: 101, Name: "Section C"})
+	fixtures.Enrollments = append(fixtures.Enrollments, canvas.Enrollment{
+		ID: 20, UserID: 1000, CourseID: 101, CourseSectionID: 202, Type: "StudentEnrollment", Role: "StudentEnrollment", EnrollmentState: "active",
+	})
+
+	fixtures.Assignments[0].SubmissionTypes = []string{"online_upload"}
+	fixtures.Assignments[0].AllDates = []canvas.AssignmentDate{{DueAt: dueAt, Base: true}}
+	fixtures.Assignments = append(fixtures.Assignments, canvas.Assignment{
+		ID: 302, CourseID: 101, Name: "Lab", Published: true, SubmissionTypes: []string{"online_upload"},
+		AllDates: []canvas.AssignmentDate{{DueAt: dueAt, Base: true}},
+	})
+
+	fixtures.Submissions = []canvas.Submission{
+		{ID: 1, UserID: 1000, AssignmentID: 300, WorkflowState: "unsubmitted"},
+		{ID: 2, UserID: 1000, AssignmentID: 302, WorkflowState: "unsubmitted"},
+		{ID: 3, UserID: 1001, AssignmentID: 300, WorkflowState: "unsubmitted"},
+	}
+
+	configs := []RiskConfig{
+		{AccountID: 1, MissingWeight: 1, MinRisk: 2},
+		{AccountID: 2, MissingWeight: 5},
+	}
+
+	_, router := newTestServer(t, fixtures, nil, WithRiskConfigs(configs))
+
+	var results []StudentRisk
+
+	getJSON(t, router, "/accounts/1/at-risk-students", &results)
+
+	// student 1001 only has a risk of 1, below the minimum risk of account 1
+	if len(results) != 1 {
+		t.Fatalf("got %d students, want 1: %+v", len(results), results)
+	}
+
+	result := results[0]
+
+	if result.UserID != 1000 || result.Risk != 6 || result.Missing != 2 {
+		t.Errorf("got user %d with risk %v and %d missing, want user 1000 with risk 6 and 2 missing", result.UserID, result.Risk, result.Missing)
+	}
+
+	riskByCourseID := make(map[int]float64)
+
+	for _, course := range result.Courses {
+		riskByCourseID[course.CourseID] = course.Risk
+	}
+
+	if riskByCourseID[100] != 1 || riskByCourseID[101] != 5 {
+		t.Errorf("got course risks %v, want 1 in course 100 and 5 in course 101", riskByCourseID)
+	}
+}
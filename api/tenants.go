@@ -0,0 +1,144 @@
+package api
+
+import (
+	"canvas-report/canvas"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TenantConfig describes a Canvas instance whose reports are served under /tenants/{name}.
+type TenantConfig struct {
+	Name        string `json:"name"`
+	BaseUrl     string `json:"base_url"`
+	AccessToken string `json:"access_token"`
+	PageSize    int    `json:"page_size"` // optional, the default page size is used when zero
+	WebUrl      string `json:"web_url"`   // optional, derived from the base url when empty
+
+	// OAuth2 developer key, used instead of AccessToken when RefreshToken is set
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// ParseTenantConfigs decodes a JSON array of tenant configs, e.g.
+//
+//	[{"name":"prod","base_url":"https://school.instructure.com/api/v1","access_token":"..."}]
+func ParseTenantConfigs(data string) ([]TenantConfig, error) {
+	var configs []TenantConfig
+
+	if err := json.Unmarshal([]byte(data), &configs); err != nil {
+		return nil, fmt.Errorf("error decoding tenant configs: %w", err)
+	}
+
+	names := make(map[string]bool, len(configs))
+
+	for _, config := range configs {
+		if config.Name == "" {
+			return nil, fmt.Errorf("missing tenant name")
+		}
+
+		if names[config.Name] {
+			return nil, fmt.Errorf("duplicate tenant: %s", config.Name)
+		}
+
+		names[config.Name] = true
+	}
+
+	return configs, nil
+}
+
+// NewTenantClient creates the Canvas client of the tenant.
+// Options shared by all tenants, like caching and retries, are applied before the tenant web url.
+func NewTenantClient(config TenantConfig, defaultPageSize int, opts ...canvas.ClientOption) (*canvas.CanvasClient, error) {
+	pageSize := config.PageSize
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+
+	if config.WebUrl != "" {
+		opts = append(opts[:len(opts):len(opts)], canvas.WithWebUrl(config.WebUrl))
+	}
+
+	var client *canvas.CanvasClient
+	var err error
+
+	if config.RefreshToken == "" {
+		client, err = canvas.NewCanvasClient(config.BaseUrl, config.AccessToken, pageSize, opts...)
+	} else {
+		webUrl := config.WebUrl
+		if webUrl == "" {
+			webUrl = strings.TrimSuffix(strings.TrimRight(config.BaseUrl, "/"), "/api/v1")
+		}
+
+		oauth2Config := &canvas.OAuth2Config{
+			WebUrl:       webUrl,
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+		}
+
+		// the first request renews the access token
+		tokens := oauth2Config.TokenSource(&canvas.OAuth2Token{RefreshToken: config.RefreshToken})
+
+		client, err = canvas.NewCanvasClientWithTokenSource(config.BaseUrl, tokens, pageSize, opts...)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error creating canvas client of tenant %s: %w", config.Name, err)
+	}
+
+	return client, nil
+}
+
+// WithTenant serves the reports of the Canvas instance of client under /tenants/{name}.
+func WithTenant(name string, client *canvas.CanvasClient) ControllerOption {
+	return func(c *APIController) {
+		if c.tenants == nil {
+			c.tenants = make(map[string]*canvas.CanvasClient)
+		}
+
+		c.tenants[name] = client
+	}
+}
+
+type canvasClientKey struct{}
+
+// canvasClientFromContext returns the Canvas client of the tenant the request is for.
+// It is set by the withTenant and withDefaultTenant middlewares.
+func canvasClientFromContext(ctx context.Context) *canvas.CanvasClient {
+	client, _ := ctx.Value(canvasClientKey{}).(*canvas.CanvasClient)
+	return client
+}
+
+// withTenant is a middleware that selects the Canvas client of the {tenant} url param.
+func (c *APIController) withTenant(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		client, ok := c.tenants[chi.URLParam(r, "tenant")]
+		if !ok {
+			http.Error(w, "tenant not found", http.StatusNotFound)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), canvasClientKey{}, client)))
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// withDefaultTenant is a middleware that selects the default Canvas client for routes without a tenant.
+func (c *APIController) withDefaultTenant(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if c.canvasClient == nil {
+			http.Error(w, "tenant not found", http.StatusNotFound)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), canvasClientKey{}, c.canvasClient)))
+	}
+
+	return http.HandlerFunc(fn)
+}
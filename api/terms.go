@@ -0,0 +1,141 @@
+package api
+
+import (
+	"canvas-report/canvas"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/guregu/null/v5"
+)
+
+// ReportTerm is the enrollment term of the course of a report row.
+type ReportTerm struct {
+	TermName    string    `json:"term_name"`
+	TermStartAt null.Time `json:"term_start_at"`
+	TermEndAt   null.Time `json:"term_end_at"`
+}
+
+func newReportTerm(term *canvas.Term) ReportTerm {
+	if term == nil {
+		return ReportTerm{}
+	}
+
+	return ReportTerm{
+		TermName:    term.Name,
+		TermStartAt: term.StartAt,
+		TermEndAt:   term.EndAt,
+	}
+}
+
+func (t ReportTerm) reportTerm() ReportTerm {
+	return t
+}
+
+// termRow is a report row with a ReportTerm embedded.
+type termRow interface {
+	reportTerm() ReportTerm
+}
+
+// groupByTerm sorts the rows by term start date, then term name, keeping the order of rows within a term.
+// Rows of terms without a start date come last.
+func groupByTerm[T termRow](rows []T) {
+	slices.SortStableFunc(rows, func(a, b T) int {
+		termA, termB := a.reportTerm(), b.reportTerm()
+
+		if termA.TermStartAt.Valid != termB.TermStartAt.Valid {
+			if termA.TermStartAt.Valid {
+				return -1
+			}
+
+			return 1
+		}
+
+		if c := termA.TermStartAt.Time.Compare(termB.TermStartAt.Time); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(termA.TermName, termB.TermName)
+	})
+}
+
+// termFilter selects report rows by the enrollment term of their course.
+type termFilter struct {
+	term string    // term ID or name
+	date time.Time // date within the term dates, zero for any
+}
+
+// parseTermFilter reads the "term" and "term_date" (YYYY-MM-DD) query params.
+func parseTermFilter(r *http.Request) (termFilter, error) {
+	params := r.URL.Query()
+
+	filter := termFilter{term: strings.TrimSpace(params.Get("term"))}
+
+	if value := params.Get("term_date"); value != "" {
+		date, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid term_date")
+		}
+
+		filter.date = date
+	}
+
+	return filter, nil
+}
+
+// matches reports whether the term passes the filter. Courses without term information only pass an empty filter.
+func (f termFilter) matches(term *canvas.Term) bool {
+	if f.term == "" && f.date.IsZero() {
+		return true
+	}
+
+	if term == nil {
+		return false
+	}
+
+	if f.term != "" && strconv.Itoa(term.ID) != f.term && !strings.EqualFold(term.Name, f.term) {
+		return false
+	}
+
+	return f.date.IsZero() || term.Contains(f.date)
+}
+
+// parseGroupByTerm reads the "group_by" query param, only "term" is supported.
+func parseGroupByTerm(r *http.Request) (bool, error) {
+	switch r.URL.Query().Get("group_by") {
+	case "":
+		return false, nil
+	case "term":
+		return true, nil
+	}
+
+	return false, fmt.Errorf("invalid group_by")
+}
+
+// GetTermsByAccountID returns the active enrollment terms of the given root account,
+// to pick values of the "term" filter of reports.
+func (c *APIController) GetTermsByAccountID(w http.ResponseWriter, r *http.Request) {
+	accountID, err := strconv.Atoi(chi.URLParam(r, "account_id"))
+	if err != nil || accountID <= 0 {
+		http.Error(w, "account not found", http.StatusNotFound)
+		return
+	}
+
+	client := canvasClientFromContext(r.Context())
+
+	terms, err := client.GetTermsByAccountID(r.Context(), accountID, nil)
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching terms of account: %d", accountID))
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&terms); err != nil {
+		http.Error(w, "error encoding json response", http.StatusInternalServerError)
+	}
+}
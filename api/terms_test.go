@@ -0,0 +1,95 @@
+package api
+
+import (
+	"canvas-report/canvas"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/guregu/null/v5"
+)
+
+func TestCourseReportsFilterByTerm(t *testing.T) {
+	fixtures := testFixtures()
+
+	fixtures.Terms = []canvas.Term{{ID: 1, Name: "Fall", StartAt: null.TimeFrom(time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC))}}
+	fixtures.Courses[0].EnrollmentTermID = 1
+
+	dueAt := time.Now().Add(-72 * time.Hour).Truncate(time.Second)
+
+	fixtures.Assignments[0].SubmissionTypes = []string{"online_upload"}
+	fixtures.Assignments[0].AllDates = []canvas.AssignmentDate{{DueAt: dueAt, Base: true}}
+
+	fixtures.Submissions = []canvas.Submission{
+		{
+			ID: 1, UserID: 1000, AssignmentID: 300, WorkflowState: "graded", Score: null.FloatFrom(8), GraderID: null.IntFrom(10),
+			SubmittedAt: null.StringFrom(dueAt.Format(time.RFC3339)), GradedAt: null.StringFrom(dueAt.Add(time.Hour).Format(time.RFC3339)),
+		},
+		{ID: 2, UserID: 1001, AssignmentID: 300, WorkflowState: "unsubmitted"},
+	}
+
+	_, router := newTestServer(t, fixtures, nil)
+
+	// results returns the number of results of the report
+	tests := []struct {
+		path    string
+		results func(t *testing.T, url string) int
+	}{
+		{
+			path: "/courses/100/grading-turnaround",
+			results: func(t *testing.T, url string) int {
+				var report GradingTurnaround
+				getJSON(t, router, url, &report)
+				return report.Overall.Graded
+			},
+		},
+		{
+			path: "/courses/100/missing-submissions",
+			results: func(t *testing.T, url string) int {
+				var results []MissingSubmissionsResult
+				getJSON(t, router, url, &results)
+				return len(results)
+			},
+		},
+		{
+			path: "/courses/100/grade-distribution",
+			results: func(t *testing.T, url string) int {
+				var results []AssignmentDistribution
+				getJSON(t, router, url, &results)
+				return len(results)
+			},
+		},
+		{
+			path: "/courses/100/unposted-grades",
+			results: func(t *testing.T, url string) int {
+				var result UnpostedCourse
+				getJSON(t, router, url, &result)
+				return len(result.Assignments)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			for _, query := range []string{"", "?term=Fall", "?term=1", "?term_date=2025-10-01"} {
+				if got := tt.results(t, tt.path+query); got != 1 {
+					t.Errorf("got %d results with %q, want 1", got, query)
+				}
+			}
+
+			for _, query := range []string{"?term=Spring", "?term_date=2025-08-01"} {
+				if got := tt.results(t, tt.path+query); got != 0 {
+					t.Errorf("got %d results with %q, want none", got, query)
+				}
+			}
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path+"?term_date=fall", nil))
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("got status %d with an invalid term_date, want 400", rec.Code)
+			}
+		})
+	}
+}
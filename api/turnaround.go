@@ -0,0 +1,416 @@
+package api
+
+import (
+	"canvas-report/canvas"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// defaultGradingSLA is the time teachers have to grade a submission, unless WithGradingSLA or "sla_days" sets another.
+const defaultGradingSLA = 7 * 24 * time.Hour
+
+// WithGradingSLA sets the time teachers have to grade a submission in turnaround reports,
+// e.g. the feedback turnaround policy of the school.
+func WithGradingSLA(sla time.Duration) ControllerOption {
+	return func(c *APIController) {
+		if sla > 0 {
+			c.gradingSLA = sla
+		}
+	}
+}
+
+// TurnaroundStats summarises the time from submission to grading of graded submissions.
+type TurnaroundStats struct {
+	Graded      int     `json:"graded"`
+	MedianHours float64 `json:"median_hours"`
+	P90Hours    float64 `json:"p90_hours"`
+	WithinSLA   float64 `json:"within_sla"` // share of graded submissions graded within the SLA, from 0 to 1
+}
+
+// TurnaroundWeek is the turnaround of submissions graded in the week starting on Monday WeekStart.
+type TurnaroundWeek struct {
+	WeekStart string `json:"week_start"`
+	TurnaroundStats
+}
+
+// TurnaroundGroup is the turnaround of a course, section, teacher or account.
+type TurnaroundGroup struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	TurnaroundStats
+	Weeks []TurnaroundWeek `json:"weeks"`
+}
+
+type GradingTurnaround struct {
+	SLAHours float64           `json:"sla_hours"`
+	Overall  TurnaroundGroup   `json:"overall"` // the course or account of the report
+	Courses  []TurnaroundGroup `json:"courses"`
+	Sections []TurnaroundGroup `json:"sections"`
+	Teachers []TurnaroundGroup `json:"teachers"` // by the user who graded the submission
+}
+
+// turnaroundSample is a graded submission. A student enrolled in several sections counts in each of them.
+type turnaroundSample struct {
+	course      *canvas.Course
+	sections    []*canvas.Section
+	graderID    int
+	submittedAt time.Time
+	gradedAt    time.Time
+}
+
+// GetGradingTurnaroundByCourseID retrieves the time from submission to grading in the given course,
+// per section and teacher, with weekly trends.
+// Submissions graded automatically, e.g. quizzes, are left out. "sla_days" overrides the grading SLA.
+// The report has no graded submissions when the course does not match the "term" and "term_date" filters.
+func (c *APIController) GetGradingTurnaroundByCourseID(w http.ResponseWriter, r *http.Request) {
+	courseID, err := strconv.Atoi(chi.URLParam(r, "course_id"))
+	if err != nil || courseID <= 0 {
+		http.Error(w, "course not found", http.StatusNotFound)
+		return
+	}
+
+	sla, err := c.parseGradingSLA(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	terms, err := parseTermFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	client := canvasClientFromContext(ctx)
+
+	course, err := client.GetCourseByID(ctx, courseID)
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching course: %d", courseID))
+		return
+	}
+
+	var samples []turnaroundSample
+
+	if terms.matches(course.Term) {
+		samples, err = turnaroundSamplesOfCourse(ctx, client, &course)
+		if err != nil {
+			writeCanvasError(w, err, fmt.Sprintf("error fetching graded submissions of course: %d", courseID))
+			return
+		}
+	}
+
+	report, err := c.gradingTurnaround(ctx, client, course.ID, course.Name, samples, sla)
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching graders of course: %d", courseID))
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		http.Error(w, "error encoding json response", http.StatusInternalServerError)
+	}
+}
+
+// GetGradingTurnaroundByAccountID retrieves the time from submission to grading in every course of the given account,
+// including courses of sub-accounts, per course, section and teacher, with weekly trends.
+// Results can be filtered by "term" and "term_date". "sla_days" overrides the grading SLA.
+func (c *APIController) GetGradingTurnaroundByAccountID(w http.ResponseWriter, r *http.Request) {
+	accountID, err := strconv.Atoi(chi.URLParam(r, "account_id"))
+	if err != nil || accountID <= 0 {
+		http.Error(w, "account not found", http.StatusNotFound)
+		return
+	}
+
+	terms, err := parseTermFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sla, err := c.parseGradingSLA(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	client := canvasClientFromContext(ctx)
+
+	account, err := client.GetAccountByID(ctx, accountID)
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching account: %d", accountID))
+		return
+	}
+
+	courses, err := coursesOfAccount(ctx, client, accountID, terms)
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching courses of account: %d", accountID))
+		return
+	}
+
+	samplesByCourse := make([][]turnaroundSample, len(courses))
+
+	err = forEach(ctx, c.reportConcurrency, len(courses), func(ctx context.Context, i int) error {
+		samples, err := turnaroundSamplesOfCourse(ctx, client, courses[i])
+		if canvas.IsNotFound(err) {
+			return nil // deleted since it was listed
+		}
+
+		if err != nil {
+			return err
+		}
+
+		samplesByCourse[i] = samples
+
+		return nil
+	})
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching graded submissions of account: %d", accountID))
+		return
+	}
+
+	report, err := c.gradingTurnaround(ctx, client, account.ID, account.Name, slices.Concat(samplesByCourse...), sla)
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching graders of account: %d", accountID))
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		http.Error(w, "error encoding json response", http.StatusInternalServerError)
+	}
+}
+
+// parseGradingSLA reads the "sla_days" query param, defaulting to the SLA of the controller.
+func (c *APIController) parseGradingSLA(r *http.Request) (time.Duration, error) {
+	value := r.URL.Query().Get("sla_days")
+	if value == "" {
+		return c.gradingSLA, nil
+	}
+
+	days, err := strconv.ParseFloat(value, 64)
+	if err != nil || days <= 0 {
+		return 0, fmt.Errorf("invalid sla_days")
+	}
+
+	return time.Duration(days * float64(24*time.Hour)), nil
+}
+
+// turnaroundSamplesOfCourse returns the graded submissions of the course with the sections of the student.
+// Submissions graded automatically, or graded before they were last submitted, are left out.
+func turnaroundSamplesOfCourse(ctx context.Context, client *canvas.CanvasClient, course *canvas.Course) ([]turnaroundSample, error) {
+	sections, err := client.GetSectionsByCourseID(ctx, course.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]turnaroundSample, 0)
+	sampleBySubmissionID := make(map[int]int) // index in samples
+
+	for _, section := range sections {
+		for submission, err := range client.IterSubmissionsBySectionID(ctx, section.ID, canvas.GradedSubmissionWorkflowState) {
+			if err != nil {
+				return nil, err
+			}
+
+			if i, ok := sampleBySubmissionID[submission.ID]; ok {
+				samples[i].sections = append(samples[i].sections, section)
+				continue
+			}
+
+			// quizzes graded by Canvas have no or a negative grader ID
+			if !submission.GraderID.Valid || submission.GraderID.Int64 <= 0 {
+				continue
+			}
+
+			submittedAt, err := time.Parse(time.RFC3339, submission.SubmittedAt.String)
+			if err != nil {
+				continue
+			}
+
+			gradedAt, err := time.Parse(time.RFC3339, submission.GradedAt.String)
+			if err != nil || gradedAt.Before(submittedAt) {
+				continue
+			}
+
+			sampleBySubmissionID[submission.ID] = len(samples)
+
+			samples = append(samples, turnaroundSample{
+				course:      course,
+				sections:    []*canvas.Section{section},
+				graderID:    int(submission.GraderID.Int64),
+				submittedAt: submittedAt,
+				gradedAt:    gradedAt,
+			})
+		}
+	}
+
+	return samples, nil
+}
+
+// gradingTurnaround groups the samples by course, section and teacher, fetching the names of the teachers.
+func (c *APIController) gradingTurnaround(ctx context.Context, client *canvas.CanvasClient, id int, name string, samples []turnaroundSample, sla time.Duration) (*GradingTurnaround, error) {
+	report := &GradingTurnaround{
+		SLAHours: roundHours(sla),
+		Overall:  newTurnaroundGroup(id, name, samples, sla),
+		Courses:  []TurnaroundGroup{},
+		Sections: []TurnaroundGroup{},
+		Teachers: []TurnaroundGroup{},
+	}
+
+	var courseIDs, sectionIDs, graderIDs []int
+
+	courses := make(map[int]*canvas.Course)
+	sections := make(map[int]*canvas.Section)
+
+	samplesByCourseID := make(map[int][]turnaroundSample)
+	samplesBySectionID := make(map[int][]turnaroundSample)
+	samplesByGraderID := make(map[int][]turnaroundSample)
+
+	for _, sample := range samples {
+		if _, ok := courses[sample.course.ID]; !ok {
+			courses[sample.course.ID] = sample.course
+			courseIDs = append(courseIDs, sample.course.ID)
+		}
+
+		samplesByCourseID[sample.course.ID] = append(samplesByCourseID[sample.course.ID], sample)
+
+		for _, section := range sample.sections {
+			if _, ok := sections[section.ID]; !ok {
+				sections[section.ID] = section
+				sectionIDs = append(sectionIDs, section.ID)
+			}
+
+			samplesBySectionID[section.ID] = append(samplesBySectionID[section.ID], sample)
+		}
+
+		if _, ok := samplesByGraderID[sample.graderID]; !ok {
+			graderIDs = append(graderIDs, sample.graderID)
+		}
+
+		samplesByGraderID[sample.graderID] = append(samplesByGraderID[sample.graderID], sample)
+	}
+
+	for _, courseID := range courseIDs {
+		report.Courses = append(report.Courses, newTurnaroundGroup(courseID, courses[courseID].Name, samplesByCourseID[courseID], sla))
+	}
+
+	for _, sectionID := range sectionIDs {
+		report.Sections = append(report.Sections, newTurnaroundGroup(sectionID, sections[sectionID].Name, samplesBySectionID[sectionID], sla))
+	}
+
+	var mu sync.Mutex
+
+	err := forEach(ctx, c.reportConcurrency, len(graderIDs), func(ctx context.Context, i int) error {
+		graderID := graderIDs[i]
+
+		grader, err := client.GetUserByID(ctx, graderID)
+		if err != nil && !canvas.IsNotFound(err) {
+			return err
+		}
+
+		group := newTurnaroundGroup(graderID, grader.Name, samplesByGraderID[graderID], sla)
+
+		mu.Lock()
+		report.Teachers = append(report.Teachers, group)
+		mu.Unlock()
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(report.Teachers, func(a, b TurnaroundGroup) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+
+	return report, nil
+}
+
+func newTurnaroundGroup(id int, name string, samples []turnaroundSample, sla time.Duration) TurnaroundGroup {
+	group := TurnaroundGroup{
+		ID:              id,
+		Name:            name,
+		TurnaroundStats: newTurnaroundStats(samples, sla),
+		Weeks:           []TurnaroundWeek{},
+	}
+
+	samplesByWeek := make(map[string][]turnaroundSample)
+
+	for _, sample := range samples {
+		week := weekStart(sample.gradedAt)
+		samplesByWeek[week] = append(samplesByWeek[week], sample)
+	}
+
+	for week, weekSamples := range samplesByWeek {
+		group.Weeks = append(group.Weeks, TurnaroundWeek{WeekStart: week, TurnaroundStats: newTurnaroundStats(weekSamples, sla)})
+	}
+
+	slices.SortFunc(group.Weeks, func(a, b TurnaroundWeek) int {
+		return cmp.Compare(a.WeekStart, b.WeekStart)
+	})
+
+	return group
+}
+
+func newTurnaroundStats(samples []turnaroundSample, sla time.Duration) TurnaroundStats {
+	if len(samples) == 0 {
+		return TurnaroundStats{}
+	}
+
+	turnarounds := make([]time.Duration, 0, len(samples))
+	withinSLA := 0
+
+	for _, sample := range samples {
+		turnaround := sample.gradedAt.Sub(sample.submittedAt)
+		turnarounds = append(turnarounds, turnaround)
+
+		if turnaround <= sla {
+			withinSLA++
+		}
+	}
+
+	slices.Sort(turnarounds)
+
+	return TurnaroundStats{
+		Graded:      len(samples),
+		MedianHours: roundHours(percentile(turnarounds, 0.5)),
+		P90Hours:    roundHours(percentile(turnarounds, 0.9)),
+		WithinSLA:   math.Round(float64(withinSLA)/float64(len(samples))*1000) / 1000,
+	}
+}
+
+// percentile returns the nearest rank percentile p, from 0 to 1, of the sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p * float64(len(sorted))))
+
+	return sorted[max(rank-1, 0)]
+}
+
+// roundHours returns the duration in hours, to one decimal place.
+func roundHours(d time.Duration) float64 {
+	return math.Round(d.Hours()*10) / 10
+}
+
+// weekStart returns the date of the Monday of the week of t, in UTC.
+func weekStart(t time.Time) string {
+	t = t.UTC()
+
+	offset := (int(t.Weekday()) + 6) % 7 // days since Monday
+
+	return t.AddDate(0, 0, -offset).Format(time.DateOnly)
+}
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"canvas-report/canvas"
+	"canvas-report/canvas/canvastest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/guregu/null/v5"
+)
+
+// samplesOf returns samples graded the given hours after they were submitted.
+func samplesOf(hours ...float64) []turnaroundSample {
+	submittedAt := time.Date(2026, 2, 2, 9, 0, 0, 0, time.UTC)
+
+	samples := make([]turnaroundSample, 0, len(hours))
+
+	for _, h := range hours {
+		samples = append(samples, turnaroundSample{submittedAt: submittedAt, gradedAt: submittedAt.Add(time.Duration(h * float64(time.Hour)))})
+	}
+
+	return samples
+}
+
+func TestNewTurnaroundStats(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []turnaroundSample
+		sla     time.Duration
+		want    TurnaroundStats
+	}{
+		{name: "none", sla: time.Hour},
+		{name: "odd", samples: samplesOf(5, 1, 4, 2, 3), sla: 72 * time.Hour, want: TurnaroundStats{Graded: 5, MedianHours: 3, P90Hours: 5, WithinSLA: 1}},
+		{name: "even", samples: samplesOf(4, 1, 3, 2), sla: 72 * time.Hour, want: TurnaroundStats{Graded: 4, MedianHours: 2, P90Hours: 4, WithinSLA: 1}},
+		{name: "p90 of 10", samples: samplesOf(10, 9, 8, 7, 6, 5, 4, 3, 2, 1), sla: 72 * time.Hour, want: TurnaroundStats{Graded: 10, MedianHours: 5, P90Hours: 9, WithinSLA: 1}},
+		{name: "sla boundary", samples: samplesOf(24, 24+1.0/3600, 1), sla: 24 * time.Hour, want: TurnaroundStats{Graded: 3, MedianHours: 24, P90Hours: 24, WithinSLA: 0.667}},
+		{name: "none within sla", samples: samplesOf(30, 50), sla: 24 * time.Hour, want: TurnaroundStats{Graded: 2, MedianHours: 30, P90Hours: 50}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newTurnaroundStats(tt.samples, tt.sla); got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{time.Hour, 2 * time.Hour, 3 * time.Hour, 4 * time.Hour}
+
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{p: 0, want: time.Hour},
+		{p: 0.25, want: time.Hour},
+		{p: 0.26, want: 2 * time.Hour},
+		{p: 0.5, want: 2 * time.Hour},
+		{p: 0.9, want: 4 * time.Hour},
+		{p: 1, want: 4 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("got percentile %v of %v, want %v", tt.p, got, tt.want)
+		}
+	}
+}
+
+// turnaroundFixtures extends testFixtures with submissions to assignment 300 graded by Teacher and Ada Lovelace
+// 12, 36, 48 and 24 hours after they were submitted, in the weeks of February 2 and 9, 2026.
+// Student 1001 is in both sections. A quiz graded by Canvas and a submission graded before it was submitted are left out.
+func turnaroundFixtures() canvastest.Fixtures {
+	fixtures := testFixtures()
+
+	fixtures.Users = append(fixtures.Users, canvas.User{ID: 11, Name: "Ada Lovelace"})
+	fixtures.Enrollments = append(fixtures.Enrollments, canvas.Enrollment{
+		ID: 30, UserID: 1001, CourseID: 100, CourseSectionID: 201, Type: "StudentEnrollment", Role: "StudentEnrollment", EnrollmentState: "active",
+	})
+
+	fixtures.Submissions = []canvas.Submission{
+		gradedSubmission(1, 1000, 300, 10, 0, 12),
+		gradedSubmission(2, 1001, 300, 10, 0, 36),
+		gradedSubmission(3, 1002, 300, 11, 0, 48),
+		gradedSubmission(4, 1004, 300, 11, 7*24, 24),
+		gradedSubmission(5, 1003, 300, -1, 0, 1),
+		gradedSubmission(6, 1005, 300, 10, 0, -1),
+	}
+
+	return fixtures
+}
+
+// gradedSubmission is a submission graded by the grader the given hours after it was submitted,
+// submitted the given hours after Monday February 2, 2026.
+func gradedSubmission(id, userID, assignmentID, graderID int, submittedHours, turnaroundHours int) canvas.Submission {
+	submittedAt := time.Date(2026, 2, 2, 9, 0, 0, 0, time.UTC).Add(time.Duration(submittedHours) * time.Hour)
+	gradedAt := submittedAt.Add(time.Duration(turnaroundHours) * time.Hour)
+
+	return canvas.Submission{
+		ID: id, UserID: userID, AssignmentID: assignmentID, WorkflowState: "graded", Score: null.FloatFrom(8), GraderID: null.IntFrom(int64(graderID)),
+		SubmittedAt: null.StringFrom(submittedAt.Format(time.RFC3339)), GradedAt: null.StringFrom(gradedAt.Format(time.RFC3339)),
+	}
+}
+
+func TestGetGradingTurnaroundByCourseID(t *testing.T) {
+	_, router := newTestServer(t, turnaroundFixtures(), nil)
+
+	var report GradingTurnaround
+
+	getJSON(t, router, "/courses/100/grading-turnaround?sla_days=1", &report)
+
+	if want := (TurnaroundStats{Graded: 4, MedianHours: 24, P90Hours: 48, WithinSLA: 0.5}); report.SLAHours != 24 || report.Overall.TurnaroundStats != want {
+		t.Errorf("got %+v with an SLA of %v hours, want %+v with an SLA of 24 hours", report.Overall.TurnaroundStats, report.SLAHours, want)
+	}
+
+	if report.Overall.Name != "Math" || len(report.Courses) != 1 || report.Courses[0].Graded != 4 {
+		t.Errorf("got overall %q and courses %+v, want Math only", report.Overall.Name, report.Courses)
+	}
+
+	weeks := report.Overall.Weeks
+
+	if len(weeks) != 2 || weeks[0].WeekStart != "2026-02-02" || weeks[0].Graded != 3 || weeks[1].WeekStart != "2026-02-09" || weeks[1].Graded != 1 {
+		t.Errorf("got weeks %+v, want 3 graded in the week of February 2 and 1 in the week of February 9", weeks)
+	}
+
+	// student 1001 counts in both sections
+	want := map[int]TurnaroundStats{
+		200: {Graded: 3, MedianHours: 36, P90Hours: 48, WithinSLA: 0.333},
+		201: {Graded: 2, MedianHours: 24, P90Hours: 36, WithinSLA: 0.5},
+	}
+
+	if len(report.Sections) != len(want) {
+		t.Errorf("got %d sections, want %d", len(report.Sections), len(want))
+	}
+
+	for _, section := range report.Sections {
+		if section.TurnaroundStats != want[section.ID] {
+			t.Errorf("got %+v of section %d, want %+v", section.TurnaroundStats, section.ID, want[section.ID])
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/courses/100/grading-turnaround?sla_days=soon", nil))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("got status %d with an invalid sla_days, want 400", rec.Code)
+	}
+}
+
+func TestGetGradingTurnaroundByAccountID(t *testing.T) {
+	fixtures := turnaroundFixtures()
+
+	// Teacher also grades in Optics of the Physics sub-account, where a grader has since been deleted
+	fixtures.Accounts = append(fixtures.Accounts, canvas.Account{ID: 2, Name: "Physics", ParentAccountID: null.IntFrom(1)})
+	fixtures.Courses = append(fixtures.Courses, canvas.Course{ID: 101, Name: "Optics", AccountID: 2, WorkflowState: "available"})
+	fixtures.Sections = append(fixtures.Sections, canvas.Section{ID: 210, CourseID: 101, Name: "Section O"})
+	fixtures.Assignments = append(fixtures.Assignments, canvas.Assignment{ID: 310, CourseID: 101, Name: "Lab", Published: true})
+
+	for i := range 2 {
+		fixtures.Enrollments = append(fixtures.Enrollments, canvas.Enrollment{
+			ID: 40 + i, UserID: 1006 + i, CourseID: 101, CourseSectionID: 210, Type: "StudentEnrollment", Role: "StudentEnrollment", EnrollmentState: "active",
+		})
+	}
+
+	fixtures.Submissions = append(fixtures.Submissions,
+		gradedSubmission(7, 1006, 310, 10, 24, 96),
+		gradedSubmission(8, 1007, 310, 99, 24, 2),
+	)
+
+	_, router := newTestServer(t, fixtures, nil, WithGradingSLA(72*time.Hour), WithReportConcurrency(2))
+
+	var report GradingTurnaround
+
+	getJSON(t, router, "/accounts/1/grading-turnaround", &report)
+
+	if report.SLAHours != 72 || report.Overall.Name != "Science" || report.Overall.Graded != 6 || report.Overall.WithinSLA != 0.833 {
+		t.Errorf("got overall %q %+v with an SLA of %v hours, want Science with 6 graded, 0.833 within the SLA of 72 hours",
+			report.Overall.Name, report.Overall.TurnaroundStats, report.SLAHours)
+	}
+
+	if len(report.Courses) != 2 || report.Courses[0].Name != "Math" || report.Courses[0].Graded != 4 || report.Courses[1].Name != "Optics" || report.Courses[1].Graded != 2 {
+		t.Errorf("got courses %+v, want Math with 4 graded and Optics with 2", report.Courses)
+	}
+
+	// by name, across courses, the deleted grader without a name first
+	want := []struct {
+		id    int
+		name  string
+		stats TurnaroundStats
+	}{
+		{id: 99, stats: TurnaroundStats{Graded: 1, MedianHours: 2, P90Hours: 2, WithinSLA: 1}},
+		{id: 11, name: "Ada Lovelace", stats: TurnaroundStats{Graded: 2, MedianHours: 24, P90Hours: 48, WithinSLA: 1}},
+		{id: 10, name: "Teacher", stats: TurnaroundStats{Graded: 3, MedianHours: 36, P90Hours: 96, WithinSLA: 0.667}},
+	}
+
+	if len(report.Teachers) != len(want) {
+		t.Fatalf("got %d teachers, want %d", len(report.Teachers), len(want))
+	}
+
+	for i, teacher := range report.Teachers {
+		if teacher.ID != want[i].id || teacher.Name != want[i].name || teacher.TurnaroundStats != want[i].stats {
+			t.Errorf("got teacher %d %q %+v, want %d %q %+v", teacher.ID, teacher.Name, teacher.TurnaroundStats, want[i].id, want[i].name, want[i].stats)
+		}
+	}
+
+	getJSON(t, router, "/accounts/2/grading-turnaround?sla_days=5", &report)
+
+	if report.SLAHours != 120 || report.Overall.Name != "Physics" || report.Overall.Graded != 2 || report.Overall.WithinSLA != 1 {
+		t.Errorf("got overall %q %+v with an SLA of %v hours, want Physics with 2 graded within the SLA of 120 hours",
+			report.Overall.Name, report.Overall.TurnaroundStats, report.SLAHours)
+	}
+}
@@ -0,0 +1,300 @@
+package api
+
+import (
+	"canvas-report/canvas"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/guregu/null/v5"
+)
+
+// UnpostedAssignment is an assignment with grades hidden from students, e.g. under a manual post policy.
+type UnpostedAssignment struct {
+	AssignmentID     int       `json:"assignment_id"`
+	Name             string    `json:"name"`
+	Unposted         int       `json:"unposted"` // graded submissions never posted
+	OldestGradedAt   null.Time `json:"oldest_graded_at"`
+	OldestGradedDays int       `json:"oldest_graded_days"`
+}
+
+// UnpostedCourse is a course with its unposted assignments, totalling their unposted grades.
+type UnpostedCourse struct {
+	Account          string                `json:"account"`
+	CourseID         int                   `json:"course_id"`
+	CourseName       string                `json:"course_name"`
+	Unposted         int                   `json:"unposted"`
+	OldestGradedAt   null.Time             `json:"oldest_graded_at"`
+	OldestGradedDays int                   `json:"oldest_graded_days"`
+	GradebookURL     string                `json:"gradebook_url"`
+	Assignments      []*UnpostedAssignment `json:"assignments"`
+	ReportTerm
+}
+
+// unpostedOptions are the filter and sort order of unposted grades reports.
+type unpostedOptions struct {
+	minAgeDays int
+	sort       string
+}
+
+// parseUnpostedOptions reads the "min_age_days" and "sort" query params.
+// Sort is "age" (oldest grade first, the default), "unposted" (most unposted grades first) or "course".
+func parseUnpostedOptions(r *http.Request) (unpostedOptions, error) {
+	params := r.URL.Query()
+
+	options := unpostedOptions{sort: "age"}
+
+	if value := params.Get("min_age_days"); value != "" {
+		minAgeDays, err := strconv.Atoi(value)
+		if err != nil || minAgeDays < 0 {
+			return options, fmt.Errorf("invalid min_age_days")
+		}
+
+		options.minAgeDays = minAgeDays
+	}
+
+	switch value := params.Get("sort"); value {
+	case "":
+	case "age", "unposted", "course":
+		options.sort = value
+	default:
+		return options, fmt.Errorf("invalid sort")
+	}
+
+	return options, nil
+}
+
+// apply filters the assignments of the course by age, sorts them and sums up the course totals.
+func (o unpostedOptions) apply(course *UnpostedCourse) {
+	course.Assignments = slices.DeleteFunc(course.Assignments, func(assignment *UnpostedAssignment) bool {
+		return assignment.OldestGradedDays < o.minAgeDays
+	})
+
+	slices.SortStableFunc(course.Assignments, func(a, b *UnpostedAssignment) int {
+		switch o.sort {
+		case "unposted":
+			return cmp.Or(cmp.Compare(b.Unposted, a.Unposted), cmp.Compare(a.Name, b.Name))
+		case "course":
+			return cmp.Compare(a.Name, b.Name)
+		}
+
+		return cmp.Or(compareOldest(a.OldestGradedAt, b.OldestGradedAt), cmp.Compare(a.Name, b.Name))
+	})
+
+	course.Unposted = 0
+	course.OldestGradedAt = null.Time{}
+	course.OldestGradedDays = 0
+
+	for _, assignment := range course.Assignments {
+		course.Unposted += assignment.Unposted
+
+		if compareOldest(assignment.OldestGradedAt, course.OldestGradedAt) < 0 {
+			course.OldestGradedAt = assignment.OldestGradedAt
+			course.OldestGradedDays = assignment.OldestGradedDays
+		}
+	}
+}
+
+// applyAll applies the options to every course, leaving out courses without assignments left, and sorts the courses.
+func (o unpostedOptions) applyAll(courses []*UnpostedCourse) []*UnpostedCourse {
+	for _, course := range courses {
+		o.apply(course)
+	}
+
+	courses = slices.DeleteFunc(courses, func(course *UnpostedCourse) bool {
+		return len(course.Assignments) == 0
+	})
+
+	byName := func(a, b *UnpostedCourse) int {
+		return cmp.Or(cmp.Compare(a.CourseName, b.CourseName), cmp.Compare(a.CourseID, b.CourseID))
+	}
+
+	slices.SortStableFunc(courses, func(a, b *UnpostedCourse) int {
+		switch o.sort {
+		case "unposted":
+			return cmp.Or(cmp.Compare(b.Unposted, a.Unposted), byName(a, b))
+		case "course":
+			return byName(a, b)
+		}
+
+		return cmp.Or(compareOldest(a.OldestGradedAt, b.OldestGradedAt), byName(a, b))
+	})
+
+	return courses
+}
+
+// GetUnpostedGradesByCourseID retrieves the given course with its assignments having graded submissions never posted to students.
+// Assignments can be filtered by "min_age_days" since the oldest grade, and sorted with "sort".
+// The course has no assignments when it does not match the "term" and "term_date" filters.
+func (c *APIController) GetUnpostedGradesByCourseID(w http.ResponseWriter, r *http.Request) {
+	courseID, err := strconv.Atoi(chi.URLParam(r, "course_id"))
+	if err != nil || courseID <= 0 {
+		http.Error(w, "course not found", http.StatusNotFound)
+		return
+	}
+
+	options, err := parseUnpostedOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	terms, err := parseTermFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	client := canvasClientFromContext(ctx)
+
+	course, err := client.GetCourseByID(ctx, courseID)
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching course: %d", courseID))
+		return
+	}
+
+	result := newUnpostedCourse(client, &course)
+
+	if terms.matches(course.Term) {
+		result, err = unpostedGradesOfCourse(ctx, client, &course, time.Now())
+		if err != nil {
+			writeCanvasError(w, err, fmt.Sprintf("error fetching graded submissions of course: %d", courseID))
+			return
+		}
+	}
+
+	options.apply(result)
+
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		http.Error(w, "error encoding json response", http.StatusInternalServerError)
+	}
+}
+
+// GetUnpostedGradesByAccountID retrieves the courses of the given account, including courses of sub-accounts,
+// with their assignments having graded submissions never posted to students. Courses without such assignments are left out.
+// Results can be filtered by "term", "term_date" and "min_age_days" since the oldest grade,
+// and courses as well as their assignments are sorted with "sort".
+func (c *APIController) GetUnpostedGradesByAccountID(w http.ResponseWriter, r *http.Request) {
+	accountID, err := strconv.Atoi(chi.URLParam(r, "account_id"))
+	if err != nil || accountID <= 0 {
+		http.Error(w, "account not found", http.StatusNotFound)
+		return
+	}
+
+	terms, err := parseTermFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	options, err := parseUnpostedOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	client := canvasClientFromContext(ctx)
+
+	courses, err := coursesOfAccount(ctx, client, accountID, terms)
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching courses of account: %d", accountID))
+		return
+	}
+
+	now := time.Now()
+	resultsByCourse := make([]*UnpostedCourse, len(courses))
+
+	err = forEach(ctx, c.reportConcurrency, len(courses), func(ctx context.Context, i int) error {
+		result, err := unpostedGradesOfCourse(ctx, client, courses[i], now)
+		if canvas.IsNotFound(err) {
+			return nil // deleted since it was listed
+		}
+
+		if err != nil {
+			return err
+		}
+
+		resultsByCourse[i] = result
+
+		return nil
+	})
+	if err != nil {
+		writeCanvasError(w, err, fmt.Sprintf("error fetching graded submissions of account: %d", accountID))
+		return
+	}
+
+	results := options.applyAll(slices.DeleteFunc(resultsByCourse, func(result *UnpostedCourse) bool {
+		return result == nil
+	}))
+
+	if err := json.NewEncoder(w).Encode(&results); err != nil {
+		http.Error(w, "error encoding json response", http.StatusInternalServerError)
+	}
+}
+
+// newUnpostedCourse returns the course without assignments.
+func newUnpostedCourse(client *canvas.CanvasClient, course *canvas.Course) *UnpostedCourse {
+	return &UnpostedCourse{
+		Account:      course.Account.Name,
+		CourseID:     course.ID,
+		CourseName:   course.Name,
+		GradebookURL: fmt.Sprintf(`%s/courses/%d/gradebook`, client.WebUrl, course.ID),
+		Assignments:  make([]*UnpostedAssignment, 0),
+		ReportTerm:   newReportTerm(course.Term),
+	}
+}
+
+// unpostedGradesOfCourse returns the course with its assignments having graded submissions never posted,
+// with the age of the oldest of these grades at now. Course totals are left to unpostedOptions.apply.
+func unpostedGradesOfCourse(ctx context.Context, client *canvas.CanvasClient, course *canvas.Course, now time.Time) (*UnpostedCourse, error) {
+	result := newUnpostedCourse(client, course)
+
+	assignmentByID := make(map[int]*UnpostedAssignment)
+
+	for submission, err := range client.IterSubmissionsByCourseID(ctx, course.ID, 0, canvas.GradedSubmissionWorkflowState) {
+		if err != nil {
+			return nil, err
+		}
+
+		if submission.PostedAt.Valid {
+			continue
+		}
+
+		assignment, ok := assignmentByID[submission.AssignmentID]
+		if !ok {
+			assignment = &UnpostedAssignment{
+				AssignmentID: submission.AssignmentID,
+				Name:         submission.Assignment.Name,
+			}
+
+			assignmentByID[submission.AssignmentID] = assignment
+			result.Assignments = append(result.Assignments, assignment)
+		}
+
+		assignment.Unposted++
+
+		gradedAt, err := time.Parse(time.RFC3339, submission.GradedAt.String)
+		if err != nil {
+			continue
+		}
+
+		if !assignment.OldestGradedAt.Valid || gradedAt.Before(assignment.OldestGradedAt.Time) {
+			assignment.OldestGradedAt = null.TimeFrom(gradedAt)
+			assignment.OldestGradedDays = int(now.Sub(gradedAt).Hours() / 24)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"canvas-report/canvas"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/guregu/null/v5"
+)
+
+func TestGetUnpostedGradesByAccountID(t *testing.T) {
+	fixtures := testFixtures()
+
+	fixtures.Courses = append(fixtures.Courses, canvas.Course{ID: 101, Name: "Biology", AccountID: 1, WorkflowState: "available"})
+	fixtures.Assignments = append(fixtures.Assignments,
+		canvas.Assignment{ID: 301, CourseID: 100, Name: "Project", Published: true},
+		canvas.Assignment{ID: 302, CourseID: 101, Name: "Lab", Published: true},
+	)
+
+	gradedDaysAgo := func(days int) null.String {
+		return null.StringFrom(time.Now().Add(-time.Duration(days)*24*time.Hour - time.Hour).Format(time.RFC3339))
+	}
+
+	graded := string(canvas.GradedSubmissionWorkflowState)
+
+	fixtures.Submissions = []canvas.Submission{
+		{ID: 1, UserID: 1000, AssignmentID: 300, WorkflowState: graded, GradedAt: gradedDaysAgo(10)},
+		{ID: 2, UserID: 1001, AssignmentID: 301, WorkflowState: graded, GradedAt: gradedDaysAgo(2)},
+		{ID: 3, UserID: 1002, AssignmentID: 301, WorkflowState: graded, GradedAt: gradedDaysAgo(1)},
+		{ID: 4, UserID: 1003, AssignmentID: 301, WorkflowState: graded, GradedAt: gradedDaysAgo(20), PostedAt: gradedDaysAgo(19)},
+		{ID: 5, UserID: 1000, AssignmentID: 302, WorkflowState: graded, GradedAt: gradedDaysAgo(5)},
+	}
+
+	_, router := newTestServer(t, fixtures, nil)
+
+	tests := []struct {
+		query           string
+		wantCourseIDs   []int
+		wantAssignments [][]int // IDs by course
+		wantUnposted    []int   // by course
+		wantOldestDays  []int   // by course
+	}{
+		{query: "", wantCourseIDs: []int{100, 101}, wantAssignments: [][]int{{300, 301}, {302}}, wantUnposted: []int{3, 1}, wantOldestDays: []int{10, 5}},
+		{query: "?sort=unposted", wantCourseIDs: []int{100, 101}, wantAssignments: [][]int{{301, 300}, {302}}, wantUnposted: []int{3, 1}, wantOldestDays: []int{10, 5}},
+		{query: "?sort=course", wantCourseIDs: []int{101, 100}, wantAssignments: [][]int{{302}, {300, 301}}, wantUnposted: []int{1, 3}, wantOldestDays: []int{5, 10}},
+		{query: "?min_age_days=3", wantCourseIDs: []int{100, 101}, wantAssignments: [][]int{{300}, {302}}, wantUnposted: []int{1, 1}, wantOldestDays: []int{10, 5}},
+		{query: "?min_age_days=7", wantCourseIDs: []int{100}, wantAssignments: [][]int{{300}}, wantUnposted: []int{1}, wantOldestDays: []int{10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var results []UnpostedCourse
+
+			getJSON(t, router, "/accounts/1/unposted-grades"+tt.query, &results)
+
+			if len(results) != len(tt.wantCourseIDs) {
+				t.Fatalf("got %d courses, want %d", len(results), len(tt.wantCourseIDs))
+			}
+
+			for i, result := range results {
+				if result.CourseID != tt.wantCourseIDs[i] {
+					t.Errorf("got course %d at %d, want %d", result.CourseID, i, tt.wantCourseIDs[i])
+					continue
+				}
+
+				if result.Unposted != tt.wantUnposted[i] || result.OldestGradedDays != tt.wantOldestDays[i] {
+					t.Errorf("got course %d with %d unposted, oldest %d days, want %d unposted, oldest %d days",
+						result.CourseID, result.Unposted, result.OldestGradedDays, tt.wantUnposted[i], tt.wantOldestDays[i])
+				}
+
+				assignmentIDs := make([]int, 0, len(result.Assignments))
+				for _, assignment := range result.Assignments {
+					assignmentIDs = append(assignmentIDs, assignment.AssignmentID)
+				}
+
+				if !slices.Equal(assignmentIDs, tt.wantAssignments[i]) {
+					t.Errorf("got assignments %v of course %d, want %v", assignmentIDs, result.CourseID, tt.wantAssignments[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGetUnpostedGradesByCourseID(t *testing.T) {
+	fixtures := testFixtures()
+
+	fixtures.Submissions = []canvas.Submission{
+		{ID: 1, UserID: 1000, AssignmentID: 300, WorkflowState: string(canvas.GradedSubmissionWorkflowState)},
+	}
+
+	_, router := newTestServer(t, fixtures, nil)
+
+	var result UnpostedCourse
+
+	getJSON(t, router, "/courses/100/unposted-grades", &result)
+
+	if result.CourseID != 100 || result.Unposted != 1 || len(result.Assignments) != 1 || result.Assignments[0].Name != "Homework" {
+		t.Errorf("got %+v, want course 100 with 1 unposted grade of Homework", result)
+	}
+
+	// without a graded date, the age is unknown
+	if result.OldestGradedAt.Valid || result.Assignments[0].OldestGradedAt.Valid {
+		t.Errorf("got oldest graded date %v without graded dates", result.OldestGradedAt)
+	}
+}
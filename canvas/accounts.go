@@ -1,10 +1,11 @@
 package canvas
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
-	"net/http"
+	"iter"
+	"net/url"
+	"strconv"
 
 	"github.com/guregu/null/v5"
 )
@@ -17,35 +18,148 @@ type Account struct {
 	WorkflowState   string   `json:"workflow_state"`
 }
 
+// AccountTree is an account along with its sub-accounts, recursively.
+type AccountTree struct {
+	Account
+	SubAccounts []*AccountTree `json:"sub_accounts"`
+}
+
+// Path returns the accounts from the root of the tree down to the given account,
+// or nil if the account is not in the tree.
+func (t *AccountTree) Path(accountID int) []Account {
+	if t.ID == accountID {
+		return []Account{t.Account}
+	}
+
+	for _, sub := range t.SubAccounts {
+		if path := sub.Path(accountID); path != nil {
+			return append([]Account{t.Account}, path...)
+		}
+	}
+
+	return nil
+}
+
+// IDs returns the IDs of every account in the tree, the root first.
+func (t *AccountTree) IDs() []int {
+	ids := []int{t.ID}
+
+	for _, sub := range t.SubAccounts {
+		ids = append(ids, sub.IDs()...)
+	}
+
+	return ids
+}
+
 // GetAccountByID retrieves account of given ID.
-func (c *CanvasClient) GetAccountByID(accountID int) (Account, int, error) {
+func (c *CanvasClient) GetAccountByID(ctx context.Context, accountID int) (Account, error) {
 	requestUrl := fmt.Sprintf("%s/accounts/%d", c.baseUrl, accountID)
 
-	req, err := http.NewRequest(http.MethodGet, requestUrl, nil)
+	return getOne[Account](ctx, c, requestUrl, fmt.Sprintf("account: %d", accountID))
+}
+
+// GetSubAccountsByAccountID retrieves the direct sub-accounts of the given account.
+func (c *CanvasClient) GetSubAccountsByAccountID(ctx context.Context, accountID int) ([]*Account, error) {
+	return collect(c.IterSubAccountsByAccountID(ctx, accountID))
+}
+
+// IterSubAccountsByAccountID is like GetSubAccountsByAccountID but returns an iterator that fetches pages as they are consumed.
+func (c *CanvasClient) IterSubAccountsByAccountID(ctx context.Context, accountID int) iter.Seq2[*Account, error] {
+	params := url.Values{}
+
+	params.Add("per_page", strconv.Itoa(c.pageSize))
+
+	requestUrl := fmt.Sprintf("%s/accounts/%d/sub_accounts?%s", c.baseUrl, accountID, params.Encode())
+
+	return paginate[Account](ctx, c, requestUrl, fmt.Sprintf("sub-accounts of account: %d", accountID))
+}
+
+// GetAllSubAccountsByAccountID retrieves every account below the given account, not only its direct sub-accounts.
+func (c *CanvasClient) GetAllSubAccountsByAccountID(ctx context.Context, accountID int) ([]*Account, error) {
+	return collect(c.IterAllSubAccountsByAccountID(ctx, accountID))
+}
+
+// IterAllSubAccountsByAccountID is like GetAllSubAccountsByAccountID but returns an iterator that fetches pages as they are consumed.
+func (c *CanvasClient) IterAllSubAccountsByAccountID(ctx context.Context, accountID int) iter.Seq2[*Account, error] {
+	params := url.Values{}
+
+	params.Add("recursive", "true")
+	params.Add("per_page", strconv.Itoa(c.pageSize))
+
+	requestUrl := fmt.Sprintf("%s/accounts/%d/sub_accounts?%s", c.baseUrl, accountID, params.Encode())
+
+	return paginate[Account](ctx, c, requestUrl, fmt.Sprintf("all sub-accounts of account: %d", accountID))
+}
+
+// GetAccountTree retrieves the given account and its sub-accounts, recursively.
+// The whole tree is listed at once and assembled by parent account, rather than one request per account.
+func (c *CanvasClient) GetAccountTree(ctx context.Context, accountID int) (*AccountTree, error) {
+	account, err := c.GetAccountByID(ctx, accountID)
 	if err != nil {
-		return Account{}, http.StatusInternalServerError, err
+		return nil, err
 	}
 
-	res, err := c.httpClient.Do(req)
+	subAccounts, err := c.GetAllSubAccountsByAccountID(ctx, accountID)
 	if err != nil {
-		return Account{}, http.StatusInternalServerError, err
+		return nil, err
 	}
-	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusOK {
-		return Account{}, res.StatusCode, fmt.Errorf("error fetching account: %d", accountID)
-	}
+	children := make(map[int][]*Account)
 
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return Account{}, http.StatusInternalServerError, err
+	for _, sub := range subAccounts {
+		if sub.ParentAccountID.Valid {
+			parentID := int(sub.ParentAccountID.Int64)
+			children[parentID] = append(children[parentID], sub)
+		}
 	}
 
-	var account Account
+	tree := &AccountTree{Account: account}
+
+	buildAccountTree(tree, children, map[int]bool{account.ID: true})
+
+	return tree, nil
+}
+
+// buildAccountTree fills the sub-accounts of the tree depth first.
+// Visited guards against cycles, which Canvas should never report.
+func buildAccountTree(tree *AccountTree, children map[int][]*Account, visited map[int]bool) {
+	tree.SubAccounts = make([]*AccountTree, 0, len(children[tree.ID]))
 
-	if err := json.Unmarshal(body, &account); err != nil {
-		return Account{}, http.StatusInternalServerError, err
+	for _, account := range children[tree.ID] {
+		if visited[account.ID] {
+			continue
+		}
+
+		visited[account.ID] = true
+
+		sub := &AccountTree{Account: *account}
+
+		buildAccountTree(sub, children, visited)
+
+		tree.SubAccounts = append(tree.SubAccounts, sub)
 	}
+}
+
+// GetAccountPath retrieves the accounts from the root account down to the given account,
+// following parent accounts, e.g. faculty, school and department of a course account.
+func (c *CanvasClient) GetAccountPath(ctx context.Context, accountID int) ([]Account, error) {
+	path := make([]Account, 0)
 
-	return account, http.StatusOK, nil
+	for id := accountID; ; {
+		account, err := c.GetAccountByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		path = append([]Account{account}, path...)
+
+		if !account.ParentAccountID.Valid || len(path) > maxAccountDepth {
+			return path, nil
+		}
+
+		id = int(account.ParentAccountID.Int64)
+	}
 }
+
+// maxAccountDepth stops GetAccountPath from looping on malformed parent accounts.
+const maxAccountDepth = 32
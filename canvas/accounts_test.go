@@ -0,0 +1,69 @@
+package canvas_test
+
+import (
+	"canvas-report/canvas"
+	"canvas-report/canvas/canvastest"
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/guregu/null/v5"
+)
+
+func TestGetAccountTree(t *testing.T) {
+	// more accounts than fit in a page, three levels deep, and an account outside the tree
+	fixtures := canvastest.Fixtures{
+		Accounts: []canvas.Account{
+			{ID: 1, Name: "Root"},
+			{ID: 2, Name: "Science", ParentAccountID: null.IntFrom(1)},
+			{ID: 3, Name: "Arts", ParentAccountID: null.IntFrom(1)},
+			{ID: 4, Name: "Physics", ParentAccountID: null.IntFrom(2)},
+			{ID: 5, Name: "Chemistry", ParentAccountID: null.IntFrom(2)},
+			{ID: 6, Name: "Optics", ParentAccountID: null.IntFrom(4)},
+			{ID: 7, Name: "Other"},
+			{ID: 8, Name: "Music", ParentAccountID: null.IntFrom(3)},
+			{ID: 9, Name: "Other School", ParentAccountID: null.IntFrom(7)},
+		},
+	}
+
+	srv := canvastest.NewServer(fixtures)
+	defer srv.Close()
+
+	client, err := canvas.NewCanvasClient(srv.BaseUrl(), canvastest.Token, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := client.GetAccountTree(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := tree.IDs(), []int{1, 2, 4, 6, 5, 3, 8}; !slices.Equal(got, want) {
+		t.Errorf("got account IDs %v, want %v", got, want)
+	}
+
+	var names []string
+
+	for _, account := range tree.Path(6) {
+		names = append(names, account.Name)
+	}
+
+	if want := []string{"Root", "Science", "Physics", "Optics"}; !slices.Equal(names, want) {
+		t.Errorf("got path %v, want %v", names, want)
+	}
+
+	// the account, then two pages of the 6 accounts below it, whatever the depth
+	if got := srv.Requests(); got != 3 {
+		t.Errorf("got %d requests, want 3", got)
+	}
+
+	sub, err := client.GetAccountTree(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := sub.IDs(), []int{2, 4, 6, 5}; !slices.Equal(got, want) {
+		t.Errorf("got account IDs %v, want %v", got, want)
+	}
+}
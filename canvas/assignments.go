@@ -2,9 +2,8 @@ package canvas
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
+	"iter"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -27,15 +26,16 @@ const (
 )
 
 type Assignment struct {
-	ID                         int       `json:"id"`
-	CourseID                   int       `json:"course_id"`
-	Name                       string    `json:"name"`
-	DueAt                      null.Time `json:"due_at"`
-	UnlockAt                   null.Time `json:"unlock_at"`
-	LockAt                     null.Time `json:"lock_at"`
-	NeedsGradingCount          int       `json:"needs_grading_count"`
-	Published                  bool      `json:"published"`
-	HtmlUrl                    string    `json:"html_url"`
+	ID                         int        `json:"id"`
+	CourseID                   int        `json:"course_id"`
+	Name                       string     `json:"name"`
+	PointsPossible             null.Float `json:"points_possible"`
+	DueAt                      null.Time  `json:"due_at"`
+	UnlockAt                   null.Time  `json:"unlock_at"`
+	LockAt                     null.Time  `json:"lock_at"`
+	NeedsGradingCount          int        `json:"needs_grading_count"`
+	Published                  bool       `json:"published"`
+	HtmlUrl                    string     `json:"html_url"`
 	NeedsGradingCountBySection []struct {
 		SectionID         int `json:"section_id"`
 		NeedsGradingCount int `json:"needs_grading_count"`
@@ -45,6 +45,40 @@ type Assignment struct {
 	GradingType        string           `json:"grading_type"`
 	OmitFromFinalGrade bool             `json:"omit_from_final_grade"`
 	WorkflowState      string           `json:"workflow_state"`
+	SubmissionTypes    []string         `json:"submission_types"`
+}
+
+// DateOfSection returns the dates of the assignment for students of the given section:
+// the section override when there is one, otherwise the base dates.
+// AllDates must be included, and ADHOC overrides of individual students are not considered.
+func (a *Assignment) DateOfSection(sectionID int) (AssignmentDate, bool) {
+	var base AssignmentDate
+	var hasBase bool
+
+	for _, date := range a.AllDates {
+		if date.SetType == string(CourseSectionSetType) && date.SetID.Valid && int(date.SetID.Int64) == sectionID {
+			return date, true
+		}
+
+		if date.Base {
+			base, hasBase = date, true
+		}
+	}
+
+	return base, hasBase
+}
+
+// ExpectsSubmission reports whether students submit the assignment in Canvas,
+// as opposed to e.g. on paper or not at all.
+func (a *Assignment) ExpectsSubmission() bool {
+	for _, submissionType := range a.SubmissionTypes {
+		switch submissionType {
+		case "none", "on_paper", "not_graded":
+			return false
+		}
+	}
+
+	return true
 }
 
 type SetType string
@@ -69,7 +103,12 @@ type AssignmentDate struct {
 // GetAssignmentsByCourseID retrieves assignments int the given course ID.
 // Search term and assignment bucket: past, ungraded, overdue, etc. are used to filter assignments.
 // Needs grading count by section information is included.
-func (c *CanvasClient) GetAssignmentsByCourseID(ctx context.Context, courseID int, assignmentSearchTerm string, bucket AssignmentBucket, needsGradingCountBySection bool) ([]*Assignment, int, error) {
+func (c *CanvasClient) GetAssignmentsByCourseID(ctx context.Context, courseID int, assignmentSearchTerm string, bucket AssignmentBucket, needsGradingCountBySection bool) ([]*Assignment, error) {
+	return collect(c.IterAssignmentsByCourseID(ctx, courseID, assignmentSearchTerm, bucket, needsGradingCountBySection))
+}
+
+// IterAssignmentsByCourseID is like GetAssignmentsByCourseID but returns an iterator that fetches pages as they are consumed.
+func (c *CanvasClient) IterAssignmentsByCourseID(ctx context.Context, courseID int, assignmentSearchTerm string, bucket AssignmentBucket, needsGradingCountBySection bool) iter.Seq2[*Assignment, error] {
 	params := url.Values{}
 
 	length := len(assignmentSearchTerm)
@@ -77,12 +116,16 @@ func (c *CanvasClient) GetAssignmentsByCourseID(ctx context.Context, courseID in
 	switch length {
 	case 0:
 	case 1:
-		return nil, http.StatusBadRequest, fmt.Errorf("assignments search term is less than 2 characters")
+		return failed[Assignment](&Error{
+			Resource:   fmt.Sprintf("assignments of course: %d", courseID),
+			StatusCode: http.StatusBadRequest,
+			Messages:   []string{"assignments search term is less than 2 characters"},
+		})
 	default:
 		params.Add("search_term", assignmentSearchTerm)
 	}
 
-	params.Add("page_size", strconv.Itoa(c.pageSize))
+	params.Add("per_page", strconv.Itoa(c.pageSize))
 
 	if bucket != AllAssignmentBucket {
 		params.Add("bucket", string(bucket))
@@ -95,53 +138,7 @@ func (c *CanvasClient) GetAssignmentsByCourseID(ctx context.Context, courseID in
 
 	requestUrl := fmt.Sprintf("%s/courses/%d/assignments?%s", c.baseUrl, courseID, params.Encode())
 
-	result := make([]*Assignment, 0)
-
-loop:
-	for {
-		select {
-		case <-ctx.Done():
-			return nil, http.StatusRequestTimeout, ctx.Err()
-		default:
-			req, err := http.NewRequest(http.MethodGet, requestUrl, nil)
-			if err != nil {
-				return nil, http.StatusInternalServerError, err
-			}
-
-			res, err := c.httpClient.Do(req)
-			if err != nil {
-				return nil, http.StatusInternalServerError, err
-			}
-
-			if res.StatusCode != http.StatusOK {
-				return nil, res.StatusCode, fmt.Errorf("error fetching assignments of course: %d", courseID)
-			}
-
-			body, err := io.ReadAll(res.Body)
-			res.Body.Close()
-			if err != nil {
-				return nil, http.StatusInternalServerError, err
-			}
-
-			var assignments []*Assignment
-
-			if err := json.Unmarshal(body, &assignments); err != nil {
-				return nil, http.StatusInternalServerError, err
-			}
-
-			result = append(result, assignments...)
-
-			nextUrl := getNextUrl(res.Header.Get("Link"))
-
-			if nextUrl == "" {
-				break loop
-			}
-
-			requestUrl = nextUrl
-		}
-	}
-
-	return result, http.StatusOK, nil
+	return paginate[Assignment](ctx, c, requestUrl, fmt.Sprintf("assignments of course: %d", courseID))
 }
 
 type AssignmentData struct {
@@ -160,59 +157,17 @@ type AssignmentData struct {
 	Status string `json:"status"`
 }
 
-func (c *CanvasClient) GetAssignmentsDataOfUserByCourseID(ctx context.Context, userID, courseID int) ([]*AssignmentData, int, error) {
+func (c *CanvasClient) GetAssignmentsDataOfUserByCourseID(ctx context.Context, userID, courseID int) ([]*AssignmentData, error) {
+	return collect(c.IterAssignmentsDataOfUserByCourseID(ctx, userID, courseID))
+}
+
+// IterAssignmentsDataOfUserByCourseID is like GetAssignmentsDataOfUserByCourseID but returns an iterator that fetches pages as they are consumed.
+func (c *CanvasClient) IterAssignmentsDataOfUserByCourseID(ctx context.Context, userID, courseID int) iter.Seq2[*AssignmentData, error] {
 	params := url.Values{}
 
 	params.Add("per_page", strconv.Itoa(c.pageSize))
 
 	requestUrl := fmt.Sprintf("%s/courses/%d/analytics/users/%d/assignments?%s", c.baseUrl, courseID, userID, params.Encode())
 
-	results := make([]*AssignmentData, 0)
-
-loop:
-	for {
-		select {
-		case <-ctx.Done():
-			return nil, http.StatusRequestTimeout, ctx.Err()
-		default:
-			{
-				req, err := http.NewRequest(http.MethodGet, requestUrl, nil)
-				if err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				res, err := c.httpClient.Do(req)
-				if err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				if res.StatusCode != http.StatusOK {
-					return nil, res.StatusCode, fmt.Errorf("error fetching assignment results of user: %d and course: %d", userID, courseID)
-				}
-
-				body, err := io.ReadAll(res.Body)
-				res.Body.Close()
-				if err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				var ad []*AssignmentData
-				if err := json.Unmarshal(body, &ad); err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				results = append(results, ad...)
-
-				nextUrl := getNextUrl(res.Header.Get("Link"))
-
-				if nextUrl == "" {
-					break loop
-				}
-
-				requestUrl = nextUrl
-			}
-		}
-	}
-
-	return results, http.StatusOK, nil
+	return paginate[AssignmentData](ctx, c, requestUrl, fmt.Sprintf("assignment results of user: %d and course: %d", userID, courseID))
 }
@@ -0,0 +1,430 @@
+package canvas
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachedResponse is a Canvas response kept by a Cache.
+type CachedResponse struct {
+	Body      []byte      `json:"body"`
+	Header    http.Header `json:"header"`
+	ETag      string      `json:"etag"`
+	ExpiresAt time.Time   `json:"expires_at"`
+}
+
+// Cache stores Canvas responses by key. Implementations must be safe for concurrent use.
+//
+// Expired entries should still be returned by Get, so they can be revalidated
+// with If-None-Match instead of being fetched again.
+type Cache interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, response *CachedResponse)
+}
+
+// DefaultCacheTTLs are the cache lifetimes of Canvas resources used unless WithCache is given other TTLs.
+// Resources are named after the route of the request path without IDs, e.g. "users/courses" for /users/1/courses,
+// or after its last segment, e.g. "courses" for /courses/1 and /accounts/1/courses. The route takes precedence.
+// Assignments and submissions are not cached, since reports rely on live grading numbers.
+// For the same reason, enrollments are only cached when they carry no grades, see carriesGrades.
+var DefaultCacheTTLs = map[string]time.Duration{
+	"accounts":      time.Hour,
+	"sub_accounts":  time.Hour,
+	"courses":       time.Minute * 10,
+	"users/courses": time.Minute * 5, // follows the user's enrollments
+	"sections":      time.Minute * 10,
+	"enrollments":   time.Minute * 5,
+	"users":         time.Minute * 10,
+	"terms":         time.Hour,
+}
+
+// WithCache caches GET responses of resources with a positive TTL in the given cache.
+// If ttls is nil, DefaultCacheTTLs is used.
+func WithCache(cache Cache, ttls map[string]time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		if ttls == nil {
+			ttls = DefaultCacheTTLs
+		}
+
+		o.cache = cache
+		o.cacheTTLs = ttls
+	}
+}
+
+type cacheBypassKey struct{}
+
+// WithCacheBypass returns a context whose Canvas requests skip cached responses.
+// Responses are still stored, so later requests see the fresh data.
+func WithCacheBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+func isCacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(cacheBypassKey{}).(bool)
+	return bypass
+}
+
+// cacheTransport is a custom RoundTripper that serves GET requests from a Cache,
+// revalidating expired entries with If-None-Match when Canvas provided an ETag.
+// It must run after authTransport, since cache keys include the access token.
+type cacheTransport struct {
+	Transport http.RoundTripper
+	Cache     Cache
+	TTLs      map[string]time.Duration
+}
+
+// RoundTrip returns a cached response when there is a fresh one, otherwise fetches and stores the response.
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ttl := cacheTTL(t.TTLs, req.URL.Path)
+
+	if req.Method != http.MethodGet || ttl <= 0 || carriesGrades(req) {
+		return t.Transport.RoundTrip(req)
+	}
+
+	key := cacheKey(req)
+
+	cached, ok := t.Cache.Get(key)
+
+	if ok && !isCacheBypassed(req.Context()) && time.Now().Before(cached.ExpiresAt) {
+		return cached.response(req), nil
+	}
+
+	if ok && cached.ETag != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	res, err := t.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusNotModified && ok {
+		res.Body.Close()
+
+		cached.ExpiresAt = time.Now().Add(ttl)
+		t.Cache.Set(key, cached)
+
+		return cached.response(req), nil
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return res, nil
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	header := http.Header{}
+
+	for _, name := range []string{"Content-Type", "Link", "ETag", "X-Request-Context-Id"} {
+		if value := res.Header.Get(name); value != "" {
+			header.Set(name, value)
+		}
+	}
+
+	t.Cache.Set(key, &CachedResponse{
+		Body:      body,
+		Header:    header,
+		ETag:      res.Header.Get("ETag"),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+
+	return res, nil
+}
+
+// response builds an HTTP response for the request from the cached entry.
+func (c *CachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        c.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(c.Body)),
+		ContentLength: int64(len(c.Body)),
+		Request:       req,
+	}
+}
+
+// cacheTTL returns the TTL of the resource a request path refers to, by route or else by the last segment of the route.
+func cacheTTL(ttls map[string]time.Duration, path string) time.Duration {
+	route := cacheRoute(path)
+
+	if ttl, ok := ttls[route]; ok {
+		return ttl
+	}
+
+	return ttls[cacheResource(route)]
+}
+
+// cacheRoute returns the route of a request path: its non numeric segments after the API prefix,
+// e.g. "sections/enrollments" for /api/v1/sections/1/enrollments.
+func cacheRoute(path string) string {
+	if _, rest, ok := strings.Cut(path, "/api/v1/"); ok {
+		path = rest
+	}
+
+	segments := make([]string, 0)
+
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		if _, err := strconv.Atoi(segment); err != nil && segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// cacheResource returns the resource a request path refers to: its last non numeric segment.
+func cacheResource(path string) string {
+	route := cacheRoute(path)
+
+	return route[strings.LastIndex(route, "/")+1:]
+}
+
+// carriesGrades reports whether the request lists enrollments that may include student grades,
+// i.e. enrollments not restricted to types other than students.
+func carriesGrades(req *http.Request) bool {
+	if cacheResource(req.URL.Path) != "enrollments" {
+		return false
+	}
+
+	types := req.URL.Query()["type[]"]
+
+	return len(types) == 0 || slices.Contains(types, string(StudentEnrollmentType))
+}
+
+// cacheKey identifies the request by url and a hash of the access token,
+// so responses are never shared between tokens with different permissions.
+func cacheKey(req *http.Request) string {
+	token := sha256.Sum256([]byte(req.Header.Get("Authorization")))
+
+	return hex.EncodeToString(token[:8]) + " " + req.URL.String()
+}
+
+// LRUCache is an in-memory Cache holding at most a fixed number of responses,
+// evicting the least recently used one when full.
+type LRUCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List
+}
+
+type lruEntry struct {
+	key      string
+	response *CachedResponse
+}
+
+func NewLRUCache(maxEntries int) (*LRUCache, error) {
+	if maxEntries <= 0 {
+		return nil, fmt.Errorf("invalid max entries")
+	}
+
+	cache := &LRUCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+
+	return cache, nil
+}
+
+func (c *LRUCache) Get(key string) (*CachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+
+	// copy so callers can update the entry without holding the lock
+	response := *element.Value.(*lruEntry).response
+
+	return &response, true
+}
+
+func (c *LRUCache) Set(key string, response *CachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*lruEntry).response = response
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, response: response})
+
+	if c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// DiskCache is a Cache storing each response as a JSON file in a directory,
+// so cached responses survive restarts, e.g. in /tmp of a warm Lambda.
+// It holds at most a fixed number of responses, pruning the least recently stored ones when full.
+type DiskCache struct {
+	dir        string
+	maxEntries int
+
+	mu   sync.Mutex
+	sets int // since the last prune
+}
+
+func NewDiskCache(dir string, maxEntries int) (*DiskCache, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("invalid cache directory")
+	}
+
+	if maxEntries <= 0 {
+		return nil, fmt.Errorf("invalid max entries")
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("error creating cache directory: %w", err)
+	}
+
+	cache := &DiskCache{dir: dir, maxEntries: maxEntries}
+
+	// responses left by previous runs count towards the limit
+	cache.prune()
+
+	return cache, nil
+}
+
+func (c *DiskCache) path(key string) string {
+	hash := sha256.Sum256([]byte(key))
+
+	return filepath.Join(c.dir, hex.EncodeToString(hash[:])+".json")
+}
+
+func (c *DiskCache) Get(key string) (*CachedResponse, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var response CachedResponse
+
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, false
+	}
+
+	return &response, true
+}
+
+// Set writes the response to a temporary file and renames it, so concurrent readers never see partial files.
+// Failures are ignored, the response is simply not cached.
+func (c *DiskCache) Set(key string, response *CachedResponse) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+
+	file, err := os.CreateTemp(c.dir, "tmp-*")
+	if err != nil {
+		return
+	}
+
+	_, err = file.Write(data)
+	file.Close()
+
+	if err != nil {
+		os.Remove(file.Name())
+		return
+	}
+
+	if err := os.Rename(file.Name(), c.path(key)); err != nil {
+		os.Remove(file.Name())
+		return
+	}
+
+	// listing the directory on every Set would be slow, so it is pruned once a tenth of the entries were stored,
+	// letting the cache exceed its size by as much in between
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sets++
+
+	if c.sets >= max(c.maxEntries/10, 1) {
+		c.prune()
+	}
+}
+
+// prune removes the least recently stored responses above maxEntries, along with temporary files
+// abandoned by failed writes. The caller must hold c.mu, except while the cache is being created.
+func (c *DiskCache) prune() {
+	c.sets = 0
+
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+
+	type entry struct {
+		path    string
+		modTime time.Time
+	}
+
+	entries := make([]entry, 0, len(dirEntries))
+
+	for _, dirEntry := range dirEntries {
+		info, err := dirEntry.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		path := filepath.Join(c.dir, dirEntry.Name())
+
+		if strings.HasPrefix(dirEntry.Name(), "tmp-") {
+			if time.Since(info.ModTime()) > time.Hour {
+				os.Remove(path)
+			}
+
+			continue
+		}
+
+		if filepath.Ext(dirEntry.Name()) == ".json" {
+			entries = append(entries, entry{path: path, modTime: info.ModTime()})
+		}
+	}
+
+	if len(entries) <= c.maxEntries {
+		return
+	}
+
+	slices.SortFunc(entries, func(a, b entry) int {
+		return a.modTime.Compare(b.modTime)
+	})
+
+	for _, entry := range entries[:len(entries)-c.maxEntries] {
+		os.Remove(entry.path)
+	}
+}
@@ -0,0 +1,142 @@
+package canvas
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCarriesGrades(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{url: "https://canvas.test/api/v1/sections/1/enrollments?type[]=StudentEnrollment", want: true},
+		{url: "https://canvas.test/api/v1/sections/1/enrollments?type[]=TeacherEnrollment&type[]=StudentEnrollment", want: true},
+		{url: "https://canvas.test/api/v1/users/1/enrollments?state[]=active", want: true},
+		{url: "https://canvas.test/api/v1/sections/1/enrollments?type[]=TeacherEnrollment&type[]=TaEnrollment", want: false},
+		{url: "https://canvas.test/api/v1/courses/1/sections", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := carriesGrades(req); got != tt.want {
+				t.Errorf("carriesGrades() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	ttls := map[string]time.Duration{
+		"courses":       time.Minute * 10,
+		"users/courses": time.Minute,
+		"enrollments":   time.Minute * 5,
+	}
+
+	tests := []struct {
+		path string
+		want time.Duration
+	}{
+		{path: "/api/v1/courses/1", want: time.Minute * 10},
+		{path: "/api/v1/accounts/1/courses", want: time.Minute * 10},
+		{path: "/api/v1/users/1/courses", want: time.Minute},
+		{path: "/api/v1/sections/1/enrollments", want: time.Minute * 5},
+		{path: "/api/v1/courses/1/assignments", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := cacheTTL(ttls, tt.path); got != tt.want {
+				t.Errorf("cacheTTL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiskCachePrunesLeastRecentlyStored(t *testing.T) {
+	dir := t.TempDir()
+
+	cache, err := NewDiskCache(dir, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored := time.Now().Add(-time.Hour)
+
+	// the tenth of the entries stored after the cache is full triggers pruning
+	for i := range 22 {
+		key := strconv.Itoa(i)
+
+		cache.Set(key, &CachedResponse{Body: []byte(key)})
+
+		stored = stored.Add(time.Second)
+
+		if err := os.Chtimes(cache.path(key), stored, stored); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 20 {
+		t.Fatalf("got %d cached responses, want 20", len(files))
+	}
+
+	for i := range 22 {
+		_, ok := cache.Get(strconv.Itoa(i))
+
+		if want := i >= 2; ok != want {
+			t.Errorf("response %d cached = %v, want %v", i, ok, want)
+		}
+	}
+}
+
+func TestDiskCachePrunesPreviousRuns(t *testing.T) {
+	dir := t.TempDir()
+
+	cache, err := NewDiskCache(dir, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range 10 {
+		cache.Set(strconv.Itoa(i), &CachedResponse{})
+	}
+
+	abandoned := filepath.Join(dir, "tmp-abandoned")
+
+	if err := os.WriteFile(abandoned, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	old := time.Now().Add(-2 * time.Hour)
+
+	if err := os.Chtimes(abandoned, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewDiskCache(dir, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 5 {
+		t.Errorf("got %d files, want 5 cached responses", len(files))
+	}
+}
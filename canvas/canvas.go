@@ -1,67 +1,270 @@
 package canvas
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
-	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
+// maxDerivedClients caps the clients kept by WithAccessToken, one per access token.
+const maxDerivedClients = 1000
+
 // CanvasClient is a client for interacting with the Canvas API.
 type CanvasClient struct {
-	baseUrl     string
-	accessToken string
-	pageSize    int
-	httpClient  *http.Client
-	WebUrl      string
+	baseUrl         string
+	pageSize        int
+	httpClient      *http.Client
+	rateLimiter     *rateLimiter
+	retryPolicy     RetryPolicy
+	maxPages        int
+	pageConcurrency int
+	graphqlUrl      string
+	opts            []ClientOption
+	derived         *derivedClients
+	WebUrl          string
+}
+
+// derivedClients are the clients returned by WithAccessToken, by hash of their access token.
+type derivedClients struct {
+	mu      sync.Mutex
+	clients map[string]*CanvasClient
+}
+
+// ClientOption configures optional behaviour of CanvasClient.
+type ClientOption func(*clientOptions)
+
+type clientOptions struct {
+	rateLimitThreshold float64
+	retryPolicy        RetryPolicy
+	maxPages           int
+	pageConcurrency    int
+	cache              Cache
+	cacheTTLs          map[string]time.Duration
+	transport          http.RoundTripper
+	recorder           *Recorder
+	webUrl             string
+}
+
+// WithRateLimitThreshold sets the remaining Canvas quota below which requests are slowed down.
+func WithRateLimitThreshold(threshold float64) ClientOption {
+	return func(o *clientOptions) {
+		o.rateLimitThreshold = threshold
+	}
+}
+
+// WithTransport sets the transport that sends requests to Canvas, http.DefaultTransport by default.
+// Authentication, caching and rate limiting are layered on top of it.
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(o *clientOptions) {
+		o.transport = transport
+	}
+}
+
+// WithWebUrl sets the url of the Canvas web interface used in report links,
+// for instances whose web url cannot be derived from the base url.
+func WithWebUrl(webUrl string) ClientOption {
+	return func(o *clientOptions) {
+		o.webUrl = strings.TrimRight(webUrl, "/")
+	}
 }
 
 // authTransport is a custom RoundTripper that adds the Authorization header to all requests.
 type authTransport struct {
-	Transport   http.RoundTripper
-	AccessToken string
+	Transport http.RoundTripper
+	Tokens    TokenSource
 }
 
 // RoundTrip adds the Authorization header to every request.
+// When Canvas rejects an access token that can be renewed, the request is sent once more with a new token.
 func (a *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := a.Tokens.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := a.send(req, req.Body, token)
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+
+	renewer, ok := a.Tokens.(tokenRenewer)
+	if !ok || (req.Body != nil && req.GetBody == nil) {
+		return res, nil
+	}
+
+	renewed, err := renewer.renew(req.Context(), token)
+	if err != nil || renewed == token {
+		return res, nil
+	}
+
+	body := req.Body
+
+	if req.GetBody != nil {
+		body, err = req.GetBody()
+		if err != nil {
+			return res, nil
+		}
+	}
+
+	res.Body.Close()
+
+	return a.send(req, body, renewed)
+}
+
+func (a *authTransport) send(req *http.Request, body io.ReadCloser, token string) (*http.Response, error) {
 	clonedReq := req.Clone(req.Context())
-	clonedReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", a.AccessToken))
+	clonedReq.Body = body
+	clonedReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	if userID, ok := masqueradeUserID(req.Context()); ok {
+		params := clonedReq.URL.Query()
+		params.Set("as_user_id", strconv.Itoa(userID))
+		clonedReq.URL.RawQuery = params.Encode()
+	}
+
 	return a.Transport.RoundTrip(clonedReq)
 }
 
-func NewCanvasClient(baseUrl, accessToken string, pageSize int) (*CanvasClient, error) {
+type masqueradeKey struct{}
+
+// WithMasquerade returns a context whose Canvas requests are made as the given user,
+// with the as_user_id parameter. Canvas only allows it for tokens with the "Become other users" permission.
+func WithMasquerade(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, masqueradeKey{}, userID)
+}
+
+func masqueradeUserID(ctx context.Context) (int, bool) {
+	userID, ok := ctx.Value(masqueradeKey{}).(int)
+	return userID, ok
+}
+
+func NewCanvasClient(baseUrl, accessToken string, pageSize int, opts ...ClientOption) (*CanvasClient, error) {
+	if accessToken == "" {
+		return nil, fmt.Errorf("invalid access token")
+	}
+
+	return NewCanvasClientWithTokenSource(baseUrl, staticTokenSource(accessToken), pageSize, opts...)
+}
+
+// NewCanvasClientWithTokenSource creates a client whose access tokens come from tokens,
+// e.g. the TokenSource of an OAuth2Config that renews expired tokens.
+func NewCanvasClientWithTokenSource(baseUrl string, tokens TokenSource, pageSize int, opts ...ClientOption) (*CanvasClient, error) {
 	if baseUrl == "" {
 		return nil, fmt.Errorf("invalid base url")
 	}
 
-	if accessToken == "" {
-		return nil, fmt.Errorf("invalid access token")
+	if tokens == nil {
+		return nil, fmt.Errorf("invalid token source")
 	}
 
 	if pageSize <= 0 {
 		return nil, fmt.Errorf("invalid page size")
 	}
 
+	options := clientOptions{
+		rateLimitThreshold: defaultRateLimitThreshold,
+		retryPolicy:        DefaultRetryPolicy,
+		pageConcurrency:    defaultPageConcurrency,
+		transport:          http.DefaultTransport,
+		webUrl:             getWebUrl(baseUrl),
+	}
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	limiter := newRateLimiter(options.rateLimitThreshold)
+
+	// only responses from Canvas are recorded, cached ones were recorded when first fetched
+	if options.recorder != nil {
+		options.transport = &recordTransport{
+			Transport: options.transport,
+			Recorder:  options.recorder,
+		}
+	}
+
+	// cached responses are served before the rate limiter, so they cost no Canvas quota
+	var transport http.RoundTripper = &rateLimitTransport{
+		Transport: options.transport,
+		Limiter:   limiter,
+	}
+
+	if options.cache != nil {
+		transport = &cacheTransport{
+			Transport: transport,
+			Cache:     options.cache,
+			TTLs:      options.cacheTTLs,
+		}
+	}
+
 	httpClient := &http.Client{
 		Timeout: time.Second * 10,
 		Transport: &authTransport{
-			Transport:   http.DefaultTransport,
-			AccessToken: accessToken,
+			Transport: transport,
+			Tokens:    tokens,
 		},
 	}
 
 	canvasClient := &CanvasClient{
-		baseUrl:     baseUrl,
-		accessToken: accessToken,
-		pageSize:    pageSize,
-		httpClient:  httpClient,
-		WebUrl:      getWebUrl(baseUrl),
+		baseUrl:         baseUrl,
+		pageSize:        pageSize,
+		httpClient:      httpClient,
+		rateLimiter:     limiter,
+		retryPolicy:     options.retryPolicy,
+		maxPages:        options.maxPages,
+		pageConcurrency: options.pageConcurrency,
+		graphqlUrl:      getGraphQLUrl(baseUrl),
+		opts:            opts,
+		derived:         &derivedClients{clients: make(map[string]*CanvasClient)},
+		WebUrl:          options.webUrl,
 	}
 
 	return canvasClient, nil
 }
 
+// WithAccessToken returns a client sending the same requests with another access token,
+// e.g. the token of the user a report is for, so Canvas applies their permissions.
+// The client shares the options of c, but has its own rate limit quota since Canvas tracks quotas per token.
+// Clients are reused for the same token, so its quota is tracked across requests.
+func (c *CanvasClient) WithAccessToken(accessToken string) (*CanvasClient, error) {
+	hash := sha256.Sum256([]byte(accessToken))
+	key := hex.EncodeToString(hash[:])
+
+	c.derived.mu.Lock()
+	defer c.derived.mu.Unlock()
+
+	if client, ok := c.derived.clients[key]; ok {
+		return client, nil
+	}
+
+	client, err := NewCanvasClient(c.baseUrl, accessToken, c.pageSize, c.opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// clients derived from derived clients have the same options, so they are reused too
+	client.derived = c.derived
+
+	if len(c.derived.clients) >= maxDerivedClients {
+		for key := range c.derived.clients {
+			delete(c.derived.clients, key)
+			break
+		}
+	}
+
+	c.derived.clients[key] = client
+
+	return client, nil
+}
+
 func getWebUrl(baseUrl string) string {
 	index := strings.Index(baseUrl, ".com")
 
@@ -72,34 +275,34 @@ func getWebUrl(baseUrl string) string {
 	return ""
 }
 
-// getNextUrl extracts the next url from the Link header string.
-// It returns empty string if there is no next url.
-//
-// Canvas API provides pagination information in the Link header as comma separated string:
-// Link:
-// <https://<canvas>/api/v1/courses/:id/discussion_topics.json?opaqueA>; rel="current",
-// <https://<canvas>/api/v1/courses/:id/discussion_topics.json?opaqueB>; rel="next",
-// <https://<canvas>/api/v1/courses/:id/discussion_topics.json?opaqueC>; rel="first",
-// <https://<canvas>/api/v1/courses/:id/discussion_topics.json?opaqueD>; rel="last"
-func getNextUrl(linkHeader string) string {
-	if linkHeader == "" {
-		return ""
+// getOne retrieves a single Canvas object at requestUrl and decodes it as T.
+// Resource names the object in errors, e.g. "course: 1".
+func getOne[T any](ctx context.Context, c *CanvasClient, requestUrl, resource string) (T, error) {
+	var result T
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestUrl, nil)
+	if err != nil {
+		return result, &Error{Resource: resource, URL: requestUrl, Err: err}
 	}
 
-	links := strings.Split(linkHeader, ",")
-	nextRegEx := regexp.MustCompile(`^<(.*)>; rel="next"$`)
+	res, err := c.do(req)
+	if err != nil {
+		return result, &Error{Resource: resource, URL: requestUrl, Err: err}
+	}
+	defer res.Body.Close()
 
-	for _, link := range links {
-		if nextRegEx.MatchString(strings.TrimSpace(link)) {
+	if res.StatusCode != http.StatusOK {
+		return result, newResponseError(res, resource)
+	}
 
-			start := strings.Index(link, "<")
-			end := strings.Index(link, ">")
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return result, &Error{Resource: resource, URL: requestUrl, Err: err}
+	}
 
-			if start != -1 && end != -1 && end > start {
-				return link[start+1 : end]
-			}
-		}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return result, &Error{Resource: resource, URL: requestUrl, Err: fmt.Errorf("error decoding response: %w", err)}
 	}
 
-	return ""
+	return result, nil
 }
@@ -0,0 +1,226 @@
+package canvas_test
+
+import (
+	"canvas-report/canvas"
+	"canvas-report/canvas/canvastest"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fastRetries retries transient failures without slowing tests down.
+var fastRetries = canvas.RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond * 10}
+
+func TestGetTermsByAccountIDAcrossPages(t *testing.T) {
+	for _, concurrency := range []int{1, 4} {
+		t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+			fixtures := canvastest.Fixtures{
+				Accounts: []canvas.Account{{ID: 1, Name: "Root"}},
+			}
+
+			for id := 1; id <= 25; id++ {
+				fixtures.Terms = append(fixtures.Terms, canvas.Term{ID: id, Name: fmt.Sprintf("Term %d", id)})
+			}
+
+			srv := canvastest.NewServer(fixtures)
+			defer srv.Close()
+
+			client, err := srv.Client(canvas.WithPageConcurrency(concurrency))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			terms, err := client.GetTermsByAccountID(context.Background(), 1, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(terms) != 25 {
+				t.Fatalf("got %d terms, want 25", len(terms))
+			}
+
+			for i, term := range terms {
+				if term.ID != i+1 {
+					t.Fatalf("term %d has id %d, want terms in page order", i, term.ID)
+				}
+			}
+
+			if got := srv.Requests(); got != 3 {
+				t.Errorf("got %d requests, want 3", got)
+			}
+		})
+	}
+}
+
+func TestInjectedErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		is     func(error) bool
+	}{
+		{status: http.StatusBadRequest},
+		{status: http.StatusUnauthorized, is: canvas.IsUnauthorized},
+		{status: http.StatusForbidden, is: canvas.IsForbidden},
+		{status: http.StatusNotFound, is: canvas.IsNotFound},
+		{status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			srv := newCoursesServer(t, 1)
+
+			client, err := srv.Client(canvas.WithRetryPolicy(canvas.RetryPolicy{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			srv.InjectError("/courses/1", tt.status, 1)
+
+			_, err = client.GetCourseByID(context.Background(), 1)
+
+			var canvasErr *canvas.Error
+			if !errors.As(err, &canvasErr) {
+				t.Fatalf("got error %v, want *canvas.Error", err)
+			}
+
+			if canvasErr.StatusCode != tt.status {
+				t.Errorf("got status %d, want %d", canvasErr.StatusCode, tt.status)
+			}
+
+			if want := []string{"injected error"}; !reflect.DeepEqual(canvasErr.Messages, want) {
+				t.Errorf("got messages %v, want %v", canvasErr.Messages, want)
+			}
+
+			if canvasErr.RequestID == "" {
+				t.Error("got no request id")
+			}
+
+			if tt.is != nil && !tt.is(err) {
+				t.Errorf("got error %v, want it classified as status %d", err, tt.status)
+			}
+
+			if canvas.IsRateLimited(err) {
+				t.Errorf("got error %v classified as rate limited", err)
+			}
+
+			// the error is injected once
+			if _, err := client.GetCourseByID(context.Background(), 1); err != nil {
+				t.Errorf("got error %v after the injected one", err)
+			}
+		})
+	}
+}
+
+func TestRetriesTransientErrors(t *testing.T) {
+	srv := newCoursesServer(t, 1)
+
+	client, err := srv.Client(canvas.WithRetryPolicy(fastRetries))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.InjectError("/courses/1", http.StatusServiceUnavailable, 2)
+
+	course, err := client.GetCourseByID(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if course.ID != 1 {
+		t.Errorf("got course %d, want 1", course.ID)
+	}
+
+	if got := srv.Requests(); got != 3 {
+		t.Errorf("got %d requests, want 3", got)
+	}
+}
+
+func TestRateLimitExceeded(t *testing.T) {
+	srv := newCoursesServer(t, 1)
+
+	client, err := srv.Client(canvas.WithRetryPolicy(canvas.RetryPolicy{}), canvas.WithRateLimitThreshold(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.SetRateLimit(0.5, 1)
+
+	_, err = client.GetCourseByID(context.Background(), 1)
+
+	if !canvas.IsRateLimited(err) {
+		t.Errorf("got error %v, want rate limited", err)
+	}
+
+	if canvas.IsForbidden(err) {
+		t.Errorf("got error %v classified as forbidden", err)
+	}
+}
+
+func TestRateLimitExceededIsRetried(t *testing.T) {
+	srv := newCoursesServer(t, 1)
+
+	// the client waits for the quota it expects Canvas to refill before retrying
+	client, err := srv.Client(canvas.WithRetryPolicy(fastRetries), canvas.WithRateLimitThreshold(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.SetRateLimit(0.5, 1)
+	srv.SetRateLimitRefill(100)
+
+	course, err := client.GetCourseByID(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if course.ID != 1 {
+		t.Errorf("got course %d, want 1", course.ID)
+	}
+
+	if got := srv.Requests(); got < 2 {
+		t.Errorf("got %d requests, want the throttled request retried", got)
+	}
+}
+
+func TestWithAccessTokenReusesClients(t *testing.T) {
+	srv := newCoursesServer(t, 1)
+
+	client, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	derived, err := client.WithAccessToken(canvastest.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := derived.GetCourseByID(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := client.WithAccessToken(canvastest.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if again != derived {
+		t.Error("got a new client for the same token")
+	}
+
+	if again.RateLimit().UpdatedAt.IsZero() {
+		t.Error("got the rate limit quota reset for the same token")
+	}
+
+	other, err := client.WithAccessToken("other-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if other == derived {
+		t.Error("got the same client for another token")
+	}
+}
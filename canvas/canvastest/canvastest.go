@@ -0,0 +1,288 @@
+// Package canvastest provides an in-process fake Canvas API for testing the canvas and api packages offline.
+//
+// The fake serves accounts, terms, courses, sections, enrollments, assignments, submissions and users
+// from seeded Fixtures, over REST and the GraphQL queries of the canvas package,
+// paginates listings with Link headers like Canvas does,
+// and can inject errors and rate limiting.
+package canvastest
+
+import (
+	"canvas-report/canvas"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Token is the access token accepted by the fake server.
+const Token = "canvastest-token"
+
+// rateLimitQuota is the quota of a fresh access token, like Canvas.
+const rateLimitQuota = 700
+
+// Fixtures is the data served by the fake Canvas.
+// Relations are resolved by IDs, e.g. enrollments are embedded with the user of Enrollment.UserID.
+type Fixtures struct {
+	Accounts    []canvas.Account
+	Terms       []canvas.Term // terms of every account
+	Courses     []canvas.Course
+	Sections    []canvas.Section
+	Enrollments []canvas.Enrollment
+	Assignments []canvas.Assignment
+	Submissions []canvas.Submission
+	Users       []canvas.User
+}
+
+type injectedError struct {
+	path   string
+	status int
+	times  int
+}
+
+// Server is a fake Canvas API served by an httptest.Server.
+type Server struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	fixtures   Fixtures
+	errors     []*injectedError
+	remaining  float64
+	cost       float64
+	refill     float64 // quota refilled per second
+	refilledAt time.Time
+	requests   int
+}
+
+// NewServer starts a fake Canvas serving the given fixtures. Callers must call Close when done.
+func NewServer(fixtures Fixtures) *Server {
+	s := &Server{
+		fixtures:  fixtures,
+		remaining: rateLimitQuota,
+	}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/v1/accounts/{id}", s.getAccount)
+	mux.HandleFunc("GET /api/v1/accounts/{id}/courses", s.getCoursesByAccount)
+	mux.HandleFunc("GET /api/v1/accounts/{id}/terms", s.getTermsByAccount)
+	mux.HandleFunc("GET /api/v1/accounts/{id}/sub_accounts", s.getSubAccounts)
+	mux.HandleFunc("GET /api/v1/courses/{id}", s.getCourse)
+	mux.HandleFunc("GET /api/v1/courses/{id}/sections", s.getSectionsByCourse)
+	mux.HandleFunc("GET /api/v1/courses/{id}/assignments", s.getAssignmentsByCourse)
+	mux.HandleFunc("GET /api/v1/courses/{id}/students/submissions", s.getSubmissionsByCourse)
+	mux.HandleFunc("GET /api/v1/courses/{id}/analytics/users/{user_id}/assignments", s.getAssignmentsDataByCourseAndUser)
+	mux.HandleFunc("GET /api/v1/sections/{id}", s.getSection)
+	mux.HandleFunc("GET /api/v1/sections/{id}/enrollments", s.getEnrollmentsBySection)
+	mux.HandleFunc("GET /api/v1/sections/{id}/students/submissions", s.getSubmissionsBySection)
+	mux.HandleFunc("GET /api/v1/users/{id}", s.getUser)
+	mux.HandleFunc("GET /api/v1/users/{id}/courses", s.getCoursesByUser)
+	mux.HandleFunc("GET /api/v1/users/{id}/enrollments", s.getEnrollmentsByUser)
+	mux.HandleFunc("GET /api/v1/users/{id}/missing_submissions", s.getMissingSubmissionsByUser)
+	mux.HandleFunc("POST /api/graphql", s.postGraphQL)
+
+	s.Server = httptest.NewServer(s.middleware(mux))
+
+	return s
+}
+
+// BaseUrl returns the Canvas API base url of the fake, to be passed to canvas.NewCanvasClient.
+func (s *Server) BaseUrl() string {
+	return s.URL + "/api/v1"
+}
+
+// Client returns a CanvasClient connected to the fake server.
+func (s *Server) Client(opts ...canvas.ClientOption) (*canvas.CanvasClient, error) {
+	return canvas.NewCanvasClient(s.BaseUrl(), Token, 10, opts...)
+}
+
+// InjectError makes the next times requests whose path contains path fail with the given status.
+func (s *Server) InjectError(path string, status int, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.errors = append(s.errors, &injectedError{path: path, status: status, times: times})
+}
+
+// SetRateLimit sets the remaining quota and the cost of every request.
+// Once the quota runs out, requests fail with 403 Forbidden (Rate Limit Exceeded) like Canvas,
+// until SetRateLimitRefill lets the quota recover.
+func (s *Server) SetRateLimit(remaining, cost float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remaining = remaining
+	s.cost = cost
+	s.refilledAt = time.Now()
+}
+
+// SetRateLimitRefill sets how much quota is refilled per second, up to the quota of a fresh token.
+// The quota is not refilled by default.
+func (s *Server) SetRateLimitRefill(perSecond float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refill = perSecond
+	s.refilledAt = time.Now()
+}
+
+// Requests returns the number of requests received so far.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+// middleware authenticates requests, charges the rate limit quota and applies injected errors.
+func (s *Server) middleware(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+
+		now := time.Now()
+
+		if s.refill > 0 {
+			s.remaining = min(s.remaining+now.Sub(s.refilledAt).Seconds()*s.refill, rateLimitQuota)
+		}
+
+		s.refilledAt = now
+		s.requests++
+		s.remaining -= s.cost
+		remaining, cost, requestID := s.remaining, s.cost, s.requests
+
+		var injected *injectedError
+
+		for _, e := range s.errors {
+			if e.times > 0 && strings.Contains(r.URL.Path, e.path) {
+				e.times--
+				injected = e
+				break
+			}
+		}
+
+		s.mu.Unlock()
+
+		w.Header().Set("X-Rate-Limit-Remaining", strconv.FormatFloat(max(remaining, 0), 'f', 3, 64))
+		w.Header().Set("X-Request-Cost", strconv.FormatFloat(cost, 'f', 3, 64))
+		w.Header().Set("X-Request-Context-Id", fmt.Sprintf("canvastest-%d", requestID))
+
+		if r.Header.Get("Authorization") != "Bearer "+Token {
+			writeError(w, http.StatusUnauthorized, "Invalid access token.")
+			return
+		}
+
+		if remaining < 0 {
+			http.Error(w, "403 Forbidden (Rate Limit Exceeded)", http.StatusForbidden)
+			return
+		}
+
+		if injected != nil {
+			writeError(w, injected.status, "injected error")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// writeError responds with a Canvas style error body.
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"errors": []map[string]string{{"message": message}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeNotFound(w http.ResponseWriter) {
+	writeError(w, http.StatusNotFound, "The specified resource does not exist.")
+}
+
+// writePage responds with the page of items requested by "page" and "per_page",
+// setting the Link header with current, next, prev, first and last relations.
+func writePage[T any](w http.ResponseWriter, r *http.Request, items []T) {
+	writeEnvelopedPage(w, r, items, "")
+}
+
+// writeEnvelopedPage is like writePage but wraps the items in an object with the envelope field,
+// like Canvas does for a few listings, e.g. {"enrollment_terms": [...]}. Empty envelope writes a plain array.
+func writeEnvelopedPage[T any](w http.ResponseWriter, r *http.Request, items []T, envelope string) {
+	params := r.URL.Query()
+
+	perPage, err := strconv.Atoi(params.Get("per_page"))
+	if err != nil || perPage <= 0 {
+		perPage = 10
+	}
+
+	page, err := strconv.Atoi(params.Get("page"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	last := max((len(items)+perPage-1)/perPage, 1)
+
+	link := func(p int, rel string) string {
+		params.Set("page", strconv.Itoa(p))
+		params.Set("per_page", strconv.Itoa(perPage))
+
+		return fmt.Sprintf(`<http://%s%s?%s>; rel="%s"`, r.Host, r.URL.Path, params.Encode(), rel)
+	}
+
+	links := []string{link(page, "current")}
+
+	if page < last {
+		links = append(links, link(page+1, "next"))
+	}
+
+	if page > 1 {
+		links = append(links, link(page-1, "prev"))
+	}
+
+	links = append(links, link(1, "first"), link(last, "last"))
+
+	w.Header().Set("Link", strings.Join(links, ","))
+
+	start := min((page-1)*perPage, len(items))
+	end := min(start+perPage, len(items))
+
+	if envelope == "" {
+		writeJSON(w, items[start:end])
+		return
+	}
+
+	writeJSON(w, map[string][]T{envelope: items[start:end]})
+}
+
+// pathID returns the integer path value of the given name, or false after responding with 404.
+func pathID(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue(name))
+	if err != nil {
+		writeNotFound(w)
+		return 0, false
+	}
+
+	return id, true
+}
+
+// hasInclude reports whether the request asks for the given include[] value.
+func hasInclude(r *http.Request, include string) bool {
+	return slices.Contains(r.URL.Query()["include[]"], include)
+}
+
+// matches reports whether value is one of the filter values, an empty filter matches everything.
+func matches(filter []string, value string) bool {
+	return len(filter) == 0 || slices.Contains(filter, value)
+}
@@ -0,0 +1,175 @@
+package canvastest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// graphqlPageSize is the number of nodes in a page of a submissions connection.
+const graphqlPageSize = 10
+
+type graphqlRequest struct {
+	Query     string            `json:"query"`
+	Variables map[string]string `json:"variables"`
+}
+
+// postGraphQL serves the GraphQL queries made by the canvas package, the student report and
+// the following pages of a course's submissions, resolved from the fixtures.
+// Other queries respond with a GraphQL error.
+func (s *Server) postGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphqlRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid graphql request")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case strings.Contains(req.Query, "query StudentReport("):
+		userID, _ := strconv.Atoi(req.Variables["userId"])
+
+		writeJSON(w, map[string]any{"data": map[string]any{"legacyNode": s.studentReportNode(userID)}})
+	case strings.Contains(req.Query, "query CourseSubmissions("):
+		courseID, _ := strconv.Atoi(req.Variables["courseId"])
+		userID, _ := strconv.Atoi(req.Variables["userId"])
+
+		var node any
+
+		if _, ok := s.courseByID(courseID); ok {
+			node = map[string]any{"submissionsConnection": s.submissionsConnection(courseID, userID, req.Variables["after"])}
+		}
+
+		writeJSON(w, map[string]any{"data": map[string]any{"legacyNode": node}})
+	default:
+		writeJSON(w, map[string]any{"errors": []map[string]string{{"message": "unsupported query"}}})
+	}
+}
+
+// studentReportNode returns the user with every enrollment, or nil when the user does not exist.
+func (s *Server) studentReportNode(userID int) any {
+	user, ok := s.user(userID)
+	if !ok {
+		return nil
+	}
+
+	enrollments := make([]map[string]any, 0)
+
+	for _, enrollment := range s.fixtures.Enrollments {
+		if enrollment.UserID != userID {
+			continue
+		}
+
+		course, ok := s.courseByID(enrollment.CourseID)
+		if !ok {
+			continue
+		}
+
+		node := map[string]any{
+			"_id":   strconv.Itoa(enrollment.ID),
+			"state": enrollment.EnrollmentState,
+			"type":  enrollment.Type,
+			"grades": map[string]any{
+				"currentScore": enrollment.Grades.CurrentScore,
+				"currentGrade": enrollment.Grades.CurrentGrade,
+				"htmlUrl":      enrollment.Grades.HtmlUrl,
+			},
+			"section": nil,
+		}
+
+		for _, section := range s.fixtures.Sections {
+			if section.ID == enrollment.CourseSectionID {
+				node["section"] = map[string]any{"_id": strconv.Itoa(section.ID), "name": section.Name, "sisId": section.SISSectionID}
+			}
+		}
+
+		courseNode := map[string]any{
+			"_id":                   strconv.Itoa(course.ID),
+			"name":                  course.Name,
+			"state":                 course.WorkflowState,
+			"account":               nil,
+			"term":                  nil,
+			"submissionsConnection": s.submissionsConnection(course.ID, userID, ""),
+		}
+
+		if account, ok := s.account(course.AccountID); ok {
+			courseNode["account"] = map[string]any{"name": account.Name}
+		}
+
+		for _, term := range s.fixtures.Terms {
+			if term.ID == course.EnrollmentTermID {
+				courseNode["term"] = map[string]any{"_id": strconv.Itoa(term.ID), "name": term.Name, "startAt": term.StartAt, "endAt": term.EndAt}
+			}
+		}
+
+		node["course"] = courseNode
+		enrollments = append(enrollments, node)
+	}
+
+	var sisID any
+	if user.SISUserID != "" {
+		sisID = user.SISUserID
+	}
+
+	return map[string]any{
+		"_id":         strconv.Itoa(user.ID),
+		"name":        user.Name,
+		"sisId":       sisID,
+		"enrollments": enrollments,
+	}
+}
+
+// submissionsConnection returns the page after the cursor of the user's submissions in the course, in assignment order.
+// Cursors are offsets into the submissions.
+func (s *Server) submissionsConnection(courseID, userID int, after string) map[string]any {
+	nodes := make([]map[string]any, 0)
+
+	for _, assignment := range s.fixtures.Assignments {
+		if assignment.CourseID != courseID {
+			continue
+		}
+
+		for _, submission := range s.fixtures.Submissions {
+			if submission.AssignmentID != assignment.ID || submission.UserID != userID {
+				continue
+			}
+
+			var dueAt any
+			if assignment.DueAt.Valid {
+				dueAt = assignment.DueAt.Time.Format(time.RFC3339)
+			}
+
+			nodes = append(nodes, map[string]any{
+				"score":       submission.Score,
+				"submittedAt": submission.SubmittedAt,
+				"postedAt":    submission.PostedAt,
+				"state":       submission.WorkflowState,
+				"late":        submission.Late,
+				"missing":     submission.Missing,
+				"assignment": map[string]any{
+					"_id":            strconv.Itoa(assignment.ID),
+					"name":           assignment.Name,
+					"pointsPossible": assignment.PointsPossible,
+					"dueAt":          dueAt,
+				},
+			})
+		}
+	}
+
+	offset, _ := strconv.Atoi(after)
+	start := min(max(offset, 0), len(nodes))
+	end := min(start+graphqlPageSize, len(nodes))
+
+	return map[string]any{
+		"nodes": nodes[start:end],
+		"pageInfo": map[string]any{
+			"hasNextPage": end < len(nodes),
+			"endCursor":   strconv.Itoa(end),
+		},
+	}
+}
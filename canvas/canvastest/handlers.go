@@ -0,0 +1,591 @@
+package canvastest
+
+import (
+	"canvas-report/canvas"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (s *Server) getAccount(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.account(id)
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+
+	writeJSON(w, account)
+}
+
+func (s *Server) getCoursesByAccount(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	params := r.URL.Query()
+	searchTerm := strings.ToLower(params.Get("search_term"))
+	types := params["enrollment_type[]"]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	courses := make([]canvas.Course, 0)
+
+	for _, course := range s.fixtures.Courses {
+		// like Canvas, courses of sub-accounts are listed too
+		if !s.isWithinAccount(course.AccountID, id) {
+			continue
+		}
+
+		if searchTerm != "" && !strings.Contains(strings.ToLower(course.Name), searchTerm) && !strings.Contains(strings.ToLower(course.CourseCode), searchTerm) {
+			continue
+		}
+
+		if len(types) > 0 && !s.hasEnrollmentOfTypes(course.ID, types) {
+			continue
+		}
+
+		courses = append(courses, s.course(course, r))
+	}
+
+	writePage(w, r, courses)
+}
+
+func (s *Server) getSubAccounts(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.account(id); !ok {
+		writeNotFound(w)
+		return
+	}
+
+	// like Canvas, recursive lists the whole tree below the account rather than its direct sub-accounts
+	recursive := r.URL.Query().Get("recursive") == "true"
+
+	accounts := make([]canvas.Account, 0)
+
+	for _, account := range s.fixtures.Accounts {
+		if !account.ParentAccountID.Valid {
+			continue
+		}
+
+		parentID := int(account.ParentAccountID.Int64)
+
+		if parentID == id || recursive && account.ID != id && s.isWithinAccount(parentID, id) {
+			accounts = append(accounts, account)
+		}
+	}
+
+	writePage(w, r, accounts)
+}
+
+func (s *Server) getTermsByAccount(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	states := r.URL.Query()["workflow_state[]"]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.account(id); !ok {
+		writeNotFound(w)
+		return
+	}
+
+	terms := make([]canvas.Term, 0)
+
+	for _, term := range s.fixtures.Terms {
+		// like Canvas, only active terms are listed unless states are given
+		if len(states) == 0 && term.WorkflowState != "" && term.WorkflowState != string(canvas.ActiveTermWorkflowState) {
+			continue
+		}
+
+		if len(states) > 0 && !slices.Contains(states, "all") && !matches(states, term.WorkflowState) {
+			continue
+		}
+
+		terms = append(terms, term)
+	}
+
+	writeEnvelopedPage(w, r, terms, "enrollment_terms")
+}
+
+func (s *Server) getCourse(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	course, ok := s.courseByID(id)
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+
+	writeJSON(w, s.course(course, r))
+}
+
+func (s *Server) getCoursesByUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	courses := make([]canvas.Course, 0)
+
+	for _, course := range s.fixtures.Courses {
+		// like Canvas, courses of concluded enrollments are not listed
+		enrolled := slices.ContainsFunc(s.fixtures.Enrollments, func(e canvas.Enrollment) bool {
+			return e.CourseID == course.ID && e.UserID == id && (e.EnrollmentState == "active" || e.EnrollmentState == "invited")
+		})
+
+		if enrolled {
+			courses = append(courses, s.course(course, r))
+		}
+	}
+
+	writePage(w, r, courses)
+}
+
+func (s *Server) getSection(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, section := range s.fixtures.Sections {
+		if section.ID == id {
+			writeJSON(w, section)
+			return
+		}
+	}
+
+	writeNotFound(w)
+}
+
+func (s *Server) getSectionsByCourse(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writePage(w, r, s.sectionsOfCourse(id))
+}
+
+func (s *Server) getEnrollmentsBySection(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writePage(w, r, s.enrollments(r, func(e canvas.Enrollment) bool { return e.CourseSectionID == id }))
+}
+
+func (s *Server) getEnrollmentsByUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writePage(w, r, s.enrollments(r, func(e canvas.Enrollment) bool { return e.UserID == id }))
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.user(id)
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+
+	writeJSON(w, user)
+}
+
+// getMissingSubmissionsByUser returns assignments past their base due date with an unsubmitted submission of the user.
+func (s *Server) getMissingSubmissionsByUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	assignments := make([]canvas.Assignment, 0)
+
+	for _, submission := range s.fixtures.Submissions {
+		if submission.UserID != id || submission.Excused.Bool {
+			continue
+		}
+
+		assignment, ok := s.assignment(submission.AssignmentID)
+		if !ok || !assignment.ExpectsSubmission() {
+			continue
+		}
+
+		// like Canvas, submissions marked missing are listed along with unsubmitted ones past due
+		marked := submission.Missing || submission.LatePolicyStatus.String == "missing"
+		pastDue := assignment.DueAt.Valid && assignment.DueAt.Time.Before(time.Now())
+
+		if !marked && (submission.LatePolicyStatus.Valid || submission.WorkflowState != string(canvas.UnsubmittedSubmissionWorkflowState) || !pastDue) {
+			continue
+		}
+
+		assignments = append(assignments, assignment)
+	}
+
+	writePage(w, r, assignments)
+}
+
+func (s *Server) getAssignmentsByCourse(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	params := r.URL.Query()
+	searchTerm := strings.ToLower(params.Get("search_term"))
+	bucket := canvas.AssignmentBucket(params.Get("bucket"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	assignments := make([]canvas.Assignment, 0)
+
+	for _, assignment := range s.fixtures.Assignments {
+		if assignment.CourseID != id {
+			continue
+		}
+
+		if searchTerm != "" && !strings.Contains(strings.ToLower(assignment.Name), searchTerm) {
+			continue
+		}
+
+		if bucket == canvas.UngradedAssignmentBucket && assignment.NeedsGradingCount == 0 {
+			continue
+		}
+
+		if params.Get("needs_grading_count_by_section") != "true" {
+			assignment.NeedsGradingCountBySection = nil
+		}
+
+		if !hasInclude(r, "all_dates") {
+			assignment.AllDates = nil
+		}
+
+		assignments = append(assignments, assignment)
+	}
+
+	writePage(w, r, assignments)
+}
+
+func (s *Server) getSubmissionsByCourse(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writePage(w, r, s.submissions(r, func(submission canvas.Submission, assignment canvas.Assignment) bool {
+		return assignment.CourseID == id
+	}))
+}
+
+func (s *Server) getSubmissionsBySection(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	students := make(map[int]bool)
+
+	for _, enrollment := range s.fixtures.Enrollments {
+		if enrollment.CourseSectionID == id && enrollment.Type == string(canvas.StudentEnrollmentType) {
+			students[enrollment.UserID] = true
+		}
+	}
+
+	writePage(w, r, s.submissions(r, func(submission canvas.Submission, assignment canvas.Assignment) bool {
+		return students[submission.UserID]
+	}))
+}
+
+// getAssignmentsDataByCourseAndUser derives the analytics assignment data of the user from assignments and submissions.
+func (s *Server) getAssignmentsDataByCourseAndUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	userID, ok := pathID(w, r, "user_id")
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make([]canvas.AssignmentData, 0)
+
+	for _, assignment := range s.fixtures.Assignments {
+		if assignment.CourseID != id {
+			continue
+		}
+
+		data := canvas.AssignmentData{
+			AssignmentID: assignment.ID,
+			Title:        assignment.Name,
+			Status:       "floating",
+		}
+
+		if assignment.DueAt.Valid {
+			data.DueAt = assignment.DueAt.Time.Format("2006-01-02T15:04:05Z07:00")
+		}
+
+		for _, submission := range s.fixtures.Submissions {
+			if submission.AssignmentID != assignment.ID || submission.UserID != userID {
+				continue
+			}
+
+			data.PointsPossible = assignment.PointsPossible
+			data.Submission.Score = submission.Score
+			data.Submission.SubmittedAt = submission.SubmittedAt.String
+
+			switch {
+			case submission.Late:
+				data.Status = "late"
+			case submission.SubmittedAt.Valid:
+				data.Status = "on_time"
+			default:
+				data.Status = "missing"
+			}
+		}
+
+		results = append(results, data)
+	}
+
+	writePage(w, r, results)
+}
+
+// course returns the course with account, sections and term included when requested.
+func (s *Server) course(course canvas.Course, r *http.Request) canvas.Course {
+	if hasInclude(r, "account") {
+		if account, ok := s.account(course.AccountID); ok {
+			course.Account = account
+		}
+	}
+
+	if hasInclude(r, "sections") {
+		course.Sections = s.sectionsOfCourse(course.ID)
+	}
+
+	if hasInclude(r, "term") {
+		for _, term := range s.fixtures.Terms {
+			if term.ID == course.EnrollmentTermID {
+				course.Term = &term
+			}
+		}
+	} else {
+		course.Term = nil
+	}
+
+	return course
+}
+
+func (s *Server) sectionsOfCourse(courseID int) []canvas.Section {
+	sections := make([]canvas.Section, 0)
+
+	for _, section := range s.fixtures.Sections {
+		if section.CourseID == courseID {
+			sections = append(sections, section)
+		}
+	}
+
+	return sections
+}
+
+// submissions returns the submissions matching the predicate and the "student_ids[]" and "workflow_state" filters,
+// with the assignment embedded when requested.
+func (s *Server) submissions(r *http.Request, predicate func(canvas.Submission, canvas.Assignment) bool) []canvas.Submission {
+	params := r.URL.Query()
+	studentIDs := params["student_ids[]"]
+	workflowState := params.Get("workflow_state")
+
+	submissions := make([]canvas.Submission, 0)
+
+	for _, submission := range s.fixtures.Submissions {
+		assignment, ok := s.assignment(submission.AssignmentID)
+		if !ok || !predicate(submission, assignment) {
+			continue
+		}
+
+		if !slices.Contains(studentIDs, "all") && !matches(studentIDs, strconv.Itoa(submission.UserID)) {
+			continue
+		}
+
+		if workflowState != "" && submission.WorkflowState != workflowState {
+			continue
+		}
+
+		if hasInclude(r, "assignment") {
+			submission.Assignment.ID = assignment.ID
+			submission.Assignment.Name = assignment.Name
+			submission.Assignment.PointsPossible = assignment.PointsPossible
+		}
+
+		submissions = append(submissions, submission)
+	}
+
+	return submissions
+}
+
+// enrollments returns the enrollments matching the predicate and the "state[]" and "type[]" filters,
+// with the enrolled user embedded.
+func (s *Server) enrollments(r *http.Request, predicate func(canvas.Enrollment) bool) []canvas.Enrollment {
+	params := r.URL.Query()
+
+	enrollments := make([]canvas.Enrollment, 0)
+
+	for _, enrollment := range s.fixtures.Enrollments {
+		if !predicate(enrollment) {
+			continue
+		}
+
+		if !matches(params["state[]"], enrollment.EnrollmentState) || !matches(params["type[]"], enrollment.Type) {
+			continue
+		}
+
+		if user, ok := s.user(enrollment.UserID); ok {
+			enrollment.User = user
+		}
+
+		enrollments = append(enrollments, enrollment)
+	}
+
+	return enrollments
+}
+
+func (s *Server) hasEnrollmentOfTypes(courseID int, types []string) bool {
+	for _, enrollment := range s.fixtures.Enrollments {
+		if enrollment.CourseID != courseID {
+			continue
+		}
+
+		// enrollment_type[] uses short names, e.g. "teacher" for "TeacherEnrollment"
+		short := strings.ToLower(strings.TrimSuffix(enrollment.Type, "Enrollment"))
+
+		if slices.Contains(types, short) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isWithinAccount reports whether the account is ancestorID or one of its sub-accounts, recursively.
+func (s *Server) isWithinAccount(accountID, ancestorID int) bool {
+	for depth := 0; depth < len(s.fixtures.Accounts)+1; depth++ {
+		if accountID == ancestorID {
+			return true
+		}
+
+		account, ok := s.account(accountID)
+		if !ok || !account.ParentAccountID.Valid {
+			return false
+		}
+
+		accountID = int(account.ParentAccountID.Int64)
+	}
+
+	return false
+}
+
+func (s *Server) account(id int) (canvas.Account, bool) {
+	for _, account := range s.fixtures.Accounts {
+		if account.ID == id {
+			return account, true
+		}
+	}
+
+	return canvas.Account{}, false
+}
+
+func (s *Server) courseByID(id int) (canvas.Course, bool) {
+	for _, course := range s.fixtures.Courses {
+		if course.ID == id {
+			return course, true
+		}
+	}
+
+	return canvas.Course{}, false
+}
+
+func (s *Server) user(id int) (canvas.User, bool) {
+	for _, user := range s.fixtures.Users {
+		if user.ID == id {
+			return user, true
+		}
+	}
+
+	return canvas.User{}, false
+}
+
+func (s *Server) assignment(id int) (canvas.Assignment, bool) {
+	for _, assignment := range s.fixtures.Assignments {
+		if assignment.ID == id {
+			return assignment, true
+		}
+	}
+
+	return canvas.Assignment{}, false
+}
@@ -2,9 +2,8 @@ package canvas
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
+	"iter"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -46,50 +45,31 @@ type Course struct {
 	EnrollmentTermID  int         `json:"enrollment_term_id"`
 	Account           Account     `json:"account"`
 	Sections          []Section   `json:"sections"`
+	Term              *Term       `json:"term"`
 }
 
-// GetCourseID retrieves course with given ID.
-// Account information of the course is included.
-func (c *CanvasClient) GetCourseByID(courseID int) (Course, int, error) {
+// GetCourseByID retrieves course with given ID.
+// Account and term information of the course are included.
+func (c *CanvasClient) GetCourseByID(ctx context.Context, courseID int) (Course, error) {
 	params := url.Values{}
 
 	params.Add("include[]", "account")
+	params.Add("include[]", "term")
 
 	requestUrl := fmt.Sprintf("%s/courses/%d?%s", c.baseUrl, courseID, params.Encode())
 
-	req, err := http.NewRequest(http.MethodGet, requestUrl, nil)
-	if err != nil {
-		return Course{}, http.StatusInternalServerError, err
-	}
-
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return Course{}, http.StatusInternalServerError, err
-	}
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusOK {
-		return Course{}, res.StatusCode, fmt.Errorf("error fetching course: %d", courseID)
-	}
-
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return Course{}, http.StatusInternalServerError, err
-	}
-
-	var course Course
-
-	if err := json.Unmarshal(body, &course); err != nil {
-		return course, http.StatusInternalServerError, err
-	}
-
-	return course, http.StatusOK, nil
+	return getOne[Course](ctx, c, requestUrl, fmt.Sprintf("course: %d", courseID))
 }
 
 // GetCoursesByAccountID retrieves courses for a given account ID.
-// Account information of the course is included.
+// Account and term information of the course are included.
 // If "types" is provided, only include courses with at least one user enrolled under one of the specified enrollment types.
-func (c *CanvasClient) GetCoursesByAccountID(ctx context.Context, accountID int, courseSearchTerm string, types []CourseEnrollmentType) ([]*Course, int, error) {
+func (c *CanvasClient) GetCoursesByAccountID(ctx context.Context, accountID int, courseSearchTerm string, types []CourseEnrollmentType) ([]*Course, error) {
+	return collect(c.IterCoursesByAccountID(ctx, accountID, courseSearchTerm, types))
+}
+
+// IterCoursesByAccountID is like GetCoursesByAccountID but returns an iterator that fetches pages as they are consumed.
+func (c *CanvasClient) IterCoursesByAccountID(ctx context.Context, accountID int, courseSearchTerm string, types []CourseEnrollmentType) iter.Seq2[*Course, error] {
 	params := url.Values{}
 
 	length := len(courseSearchTerm)
@@ -97,13 +77,18 @@ func (c *CanvasClient) GetCoursesByAccountID(ctx context.Context, accountID int,
 	switch length {
 	case 0:
 	case 1:
-		return nil, http.StatusBadRequest, fmt.Errorf("course search term is less than 2 characters")
+		return failed[Course](&Error{
+			Resource:   fmt.Sprintf("courses of account: %d", accountID),
+			StatusCode: http.StatusBadRequest,
+			Messages:   []string{"course search term is less than 2 characters"},
+		})
 	default:
 		params.Add("search_term", courseSearchTerm)
 	}
 
 	params.Add("per_page", strconv.Itoa(c.pageSize))
 	params.Add("include[]", "account")
+	params.Add("include[]", "term")
 
 	for _, t := range types {
 		params.Add("enrollment_type[]", string(t))
@@ -111,114 +96,25 @@ func (c *CanvasClient) GetCoursesByAccountID(ctx context.Context, accountID int,
 
 	requestUrl := fmt.Sprintf("%s/accounts/%d/courses?%s", c.baseUrl, accountID, params.Encode())
 
-	result := make([]*Course, 0)
-
-loop:
-	for {
-		select {
-		case <-ctx.Done():
-			return nil, http.StatusRequestTimeout, ctx.Err()
-		default:
-			{
-				req, err := http.NewRequest(http.MethodGet, requestUrl, nil)
-				if err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				res, err := c.httpClient.Do(req)
-				if err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				if res.StatusCode != http.StatusOK {
-					return nil, res.StatusCode, fmt.Errorf("error fetching courses of account: %d", accountID)
-				}
-
-				body, err := io.ReadAll(res.Body)
-				res.Body.Close()
-				if err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				var courses []*Course
-
-				if err := json.Unmarshal(body, &courses); err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				result = append(result, courses...)
-
-				nextUrl := getNextUrl(res.Header.Get("Link"))
-
-				if nextUrl == "" {
-					break loop
-				}
-
-				requestUrl = nextUrl
-			}
-		}
-	}
-
-	return result, http.StatusOK, nil
+	return paginate[Course](ctx, c, requestUrl, fmt.Sprintf("courses of account: %d", accountID))
 }
 
 // GetCoursesByUserID retrieves active courses for a given user ID.
-// Account and section information are included.
-func (c *CanvasClient) GetCoursesByUserID(ctx context.Context, userID int) ([]*Course, int, error) {
+// Account, section and term information are included.
+func (c *CanvasClient) GetCoursesByUserID(ctx context.Context, userID int) ([]*Course, error) {
+	return collect(c.IterCoursesByUserID(ctx, userID))
+}
+
+// IterCoursesByUserID is like GetCoursesByUserID but returns an iterator that fetches pages as they are consumed.
+func (c *CanvasClient) IterCoursesByUserID(ctx context.Context, userID int) iter.Seq2[*Course, error] {
 	params := url.Values{}
 
 	params.Add("per_page", strconv.Itoa(c.pageSize))
 	params.Add("include[]", "account")
 	params.Add("include[]", "sections")
+	params.Add("include[]", "term")
 
 	requestUrl := fmt.Sprintf("%s/users/%d/courses?%s", c.baseUrl, userID, params.Encode())
 
-	result := make([]*Course, 0)
-
-loop:
-	for {
-		select {
-		case <-ctx.Done():
-			return nil, http.StatusRequestTimeout, ctx.Err()
-		default:
-			{
-				req, err := http.NewRequest(http.MethodGet, requestUrl, nil)
-				if err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				res, err := c.httpClient.Do(req)
-				if err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				if res.StatusCode != http.StatusOK {
-					return nil, res.StatusCode, fmt.Errorf("error fetching courses of user: %d", userID)
-				}
-
-				body, err := io.ReadAll(res.Body)
-				res.Body.Close()
-				if err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				var courses []*Course
-				if err := json.Unmarshal(body, &courses); err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				result = append(result, courses...)
-
-				nextUrl := getNextUrl(res.Header.Get("Link"))
-
-				if nextUrl == "" {
-					break loop
-				}
-
-				requestUrl = nextUrl
-			}
-		}
-	}
-
-	return result, http.StatusOK, nil
+	return paginate[Course](ctx, c, requestUrl, fmt.Sprintf("courses of user: %d", userID))
 }
@@ -0,0 +1,92 @@
+package canvas_test
+
+import (
+	"canvas-report/canvas"
+	"canvas-report/canvas/canvastest"
+	"context"
+	"fmt"
+	"testing"
+)
+
+// newCoursesServer serves an account with the given number of courses, 10 per page.
+func newCoursesServer(t *testing.T, courses int) *canvastest.Server {
+	fixtures := canvastest.Fixtures{
+		Accounts: []canvas.Account{{ID: 1, Name: "Root"}},
+	}
+
+	for id := 1; id <= courses; id++ {
+		fixtures.Courses = append(fixtures.Courses, canvas.Course{ID: id, Name: fmt.Sprintf("Course %d", id), AccountID: 1})
+	}
+
+	srv := canvastest.NewServer(fixtures)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestIterCoursesByAccountIDPrefetch(t *testing.T) {
+	for _, concurrency := range []int{1, 2, 4, 16} {
+		t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+			srv := newCoursesServer(t, 95)
+
+			client, err := srv.Client(canvas.WithPageConcurrency(concurrency))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			courses, err := client.GetCoursesByAccountID(context.Background(), 1, "", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(courses) != 95 {
+				t.Fatalf("got %d courses, want 95", len(courses))
+			}
+
+			for i, course := range courses {
+				if course.ID != i+1 {
+					t.Fatalf("course %d has id %d, want courses in page order", i, course.ID)
+				}
+			}
+
+			if got := srv.Requests(); got != 10 {
+				t.Errorf("got %d requests, want 10", got)
+			}
+		})
+	}
+}
+
+func TestIterCoursesByAccountIDPrefetchStopsWhenCallerBreaks(t *testing.T) {
+	const concurrency = 3
+
+	srv := newCoursesServer(t, 95)
+
+	client, err := srv.Client(canvas.WithPageConcurrency(concurrency))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := 0
+
+	for course, err := range client.IterCoursesByAccountID(context.Background(), 1, "", nil) {
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ids++
+
+		if course.ID != ids {
+			t.Fatalf("course %d has id %d, want courses in page order", ids, course.ID)
+		}
+
+		// stop in the middle of the third page
+		if ids == 25 {
+			break
+		}
+	}
+
+	// the third page was being consumed, so at most concurrency pages beyond it were started
+	if got := srv.Requests(); got < 3 || got > 3+concurrency {
+		t.Errorf("got %d requests, want between 3 and %d", got, 3+concurrency)
+	}
+}
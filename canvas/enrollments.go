@@ -2,10 +2,8 @@ package canvas
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
+	"iter"
 	"net/url"
 	"slices"
 	"strconv"
@@ -85,7 +83,12 @@ func GetOnlyValidEnrollmentState(states []string) []EnrollmentState {
 
 // GetEnrollmentsBySectionID retrieves enrollments in the given section ID.
 // Enrollments are filtered based on enrollment states and enrollment type parameters.
-func (c *CanvasClient) GetEnrollmentsBySectionID(ctx context.Context, sectionID int, states []EnrollmentState, types []EnrollmentType) ([]*Enrollment, int, error) {
+func (c *CanvasClient) GetEnrollmentsBySectionID(ctx context.Context, sectionID int, states []EnrollmentState, types []EnrollmentType) ([]*Enrollment, error) {
+	return collect(c.IterEnrollmentsBySectionID(ctx, sectionID, states, types))
+}
+
+// IterEnrollmentsBySectionID is like GetEnrollmentsBySectionID but returns an iterator that fetches pages as they are consumed.
+func (c *CanvasClient) IterEnrollmentsBySectionID(ctx context.Context, sectionID int, states []EnrollmentState, types []EnrollmentType) iter.Seq2[*Enrollment, error] {
 	params := url.Values{}
 
 	params.Add("per_page", strconv.Itoa(c.pageSize))
@@ -100,60 +103,17 @@ func (c *CanvasClient) GetEnrollmentsBySectionID(ctx context.Context, sectionID
 
 	requestUrl := fmt.Sprintf("%s/sections/%d/enrollments?%s", c.baseUrl, sectionID, params.Encode())
 
-	result := make([]*Enrollment, 0)
-
-loop:
-	for {
-		select {
-		case <-ctx.Done():
-			return nil, http.StatusRequestTimeout, ctx.Err()
-		default:
-			{
-				req, err := http.NewRequest(http.MethodGet, requestUrl, nil)
-				if err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				res, err := c.httpClient.Do(req)
-				if err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				if res.StatusCode != http.StatusOK {
-					return nil, res.StatusCode, fmt.Errorf("error fetching enrollments of course section: %d", sectionID)
-				}
-
-				body, err := io.ReadAll(res.Body)
-				res.Body.Close()
-				if err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				var enrollments []*Enrollment
-
-				if err := json.Unmarshal(body, &enrollments); err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				result = append(result, enrollments...)
-
-				nextUrl := getNextUrl(res.Header.Get("Link"))
-
-				if nextUrl == "" {
-					break loop
-				}
-
-				requestUrl = nextUrl
-			}
-		}
-	}
-
-	return result, http.StatusOK, nil
+	return paginate[Enrollment](ctx, c, requestUrl, fmt.Sprintf("enrollments of course section: %d", sectionID))
 }
 
 // GetEnrollmentsByUserID retrieves enrollments of given user ID.
 // Enrollments are filtered based on enrollment states parameters.
-func (c *CanvasClient) GetEnrollmentsByUserID(ctx context.Context, userID int, states []EnrollmentState) ([]*Enrollment, int, error) {
+func (c *CanvasClient) GetEnrollmentsByUserID(ctx context.Context, userID int, states []EnrollmentState) ([]*Enrollment, error) {
+	return collect(c.IterEnrollmentsByUserID(ctx, userID, states))
+}
+
+// IterEnrollmentsByUserID is like GetEnrollmentsByUserID but returns an iterator that fetches pages as they are consumed.
+func (c *CanvasClient) IterEnrollmentsByUserID(ctx context.Context, userID int, states []EnrollmentState) iter.Seq2[*Enrollment, error] {
 	params := url.Values{}
 
 	params.Add("per_page", strconv.Itoa(c.pageSize))
@@ -164,53 +124,5 @@ func (c *CanvasClient) GetEnrollmentsByUserID(ctx context.Context, userID int, s
 
 	requestUrl := fmt.Sprintf("%s/users/%d/enrollments?%s", c.baseUrl, userID, params.Encode())
 
-	result := make([]*Enrollment, 0)
-
-loop:
-	for {
-		select {
-		case <-ctx.Done():
-			return nil, http.StatusRequestTimeout, ctx.Err()
-		default:
-			{
-				req, err := http.NewRequest(http.MethodGet, requestUrl, nil)
-				if err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				res, err := c.httpClient.Do(req)
-				if err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				if res.StatusCode != http.StatusOK {
-					return nil, res.StatusCode, fmt.Errorf("error fetching enrollments of user: %d", userID)
-				}
-
-				body, err := io.ReadAll(res.Body)
-				res.Body.Close()
-				if err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				var enrollments []*Enrollment
-
-				if err := json.Unmarshal(body, &enrollments); err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				result = append(result, enrollments...)
-
-				nextUrl := getNextUrl(res.Header.Get("Link"))
-
-				if nextUrl == "" {
-					break loop
-				}
-
-				requestUrl = nextUrl
-			}
-		}
-	}
-
-	return result, http.StatusOK, nil
+	return paginate[Enrollment](ctx, c, requestUrl, fmt.Sprintf("enrollments of user: %d", userID))
 }
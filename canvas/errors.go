@@ -0,0 +1,154 @@
+package canvas
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// maxErrorBodySize limits how much of a failed response body is read for error messages.
+const maxErrorBodySize = 64 << 10
+
+// Error is a failed Canvas request.
+//
+// StatusCode is zero when no response was received, in which case Err holds the cause,
+// e.g. a network error or context.Canceled.
+type Error struct {
+	Resource   string   // what was being fetched, e.g. "course: 1"
+	URL        string   // url of the request
+	StatusCode int      // HTTP status code of the Canvas response
+	Messages   []string // messages from the Canvas error body
+	RequestID  string   // Canvas request ID from the X-Request-Context-Id header
+	Err        error
+}
+
+func (e *Error) Error() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "error fetching %s", e.Resource)
+
+	if e.StatusCode != 0 {
+		fmt.Fprintf(&sb, ": status %d", e.StatusCode)
+	}
+
+	if len(e.Messages) > 0 {
+		fmt.Fprintf(&sb, ": %s", strings.Join(e.Messages, "; "))
+	}
+
+	if e.Err != nil {
+		fmt.Fprintf(&sb, ": %s", e.Err)
+	}
+
+	return sb.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// IsNotFound reports whether Canvas responded with 404 Not Found.
+func IsNotFound(err error) bool {
+	return hasStatusCode(err, http.StatusNotFound)
+}
+
+// IsUnauthorized reports whether Canvas rejected the access token with 401 Unauthorized.
+// A rejected OAuth2 refresh token is a misconfiguration of the client, reported with ErrInvalidGrant instead.
+func IsUnauthorized(err error) bool {
+	return hasStatusCode(err, http.StatusUnauthorized)
+}
+
+// IsForbidden reports whether Canvas responded with 403 Forbidden for a reason other than rate limiting.
+func IsForbidden(err error) bool {
+	return hasStatusCode(err, http.StatusForbidden) && !IsRateLimited(err)
+}
+
+// IsRateLimited reports whether Canvas throttled the request.
+// Canvas responds with 403 Forbidden and "Rate Limit Exceeded", other proxies may respond with 429.
+func IsRateLimited(err error) bool {
+	var e *Error
+
+	if !errors.As(err, &e) {
+		return false
+	}
+
+	if e.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+
+	if e.StatusCode != http.StatusForbidden {
+		return false
+	}
+
+	for _, message := range e.Messages {
+		if strings.Contains(message, "Rate Limit Exceeded") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasStatusCode(err error, code int) bool {
+	var e *Error
+
+	return errors.As(err, &e) && e.StatusCode == code
+}
+
+// newResponseError builds an Error from a non 200 OK Canvas response.
+//
+// Canvas reports errors in a few shapes:
+// {"errors":[{"message":"..."}]}, {"errors":{"field":[{"message":"..."}]}}, {"message":"..."} or plain text.
+func newResponseError(res *http.Response, resource string) *Error {
+	e := &Error{
+		Resource:   resource,
+		URL:        res.Request.URL.String(),
+		StatusCode: res.StatusCode,
+		RequestID:  res.Header.Get("X-Request-Context-Id"),
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxErrorBodySize))
+	if err != nil || len(body) == 0 {
+		return e
+	}
+
+	var payload struct {
+		Message string          `json:"message"`
+		Errors  json.RawMessage `json:"errors"`
+	}
+
+	if err := json.Unmarshal(body, &payload); err != nil {
+		e.Messages = []string{strings.TrimSpace(string(body))}
+		return e
+	}
+
+	if payload.Message != "" {
+		e.Messages = append(e.Messages, payload.Message)
+	}
+
+	var list []struct {
+		Message string `json:"message"`
+	}
+
+	var fields map[string][]struct {
+		Message string `json:"message"`
+	}
+
+	if err := json.Unmarshal(payload.Errors, &list); err == nil {
+		for _, item := range list {
+			e.Messages = append(e.Messages, item.Message)
+		}
+	} else if err := json.Unmarshal(payload.Errors, &fields); err == nil {
+		for _, field := range slices.Sorted(maps.Keys(fields)) {
+			for _, item := range fields[field] {
+				e.Messages = append(e.Messages, fmt.Sprintf("%s: %s", field, item.Message))
+			}
+		}
+	}
+
+	return e
+}
@@ -0,0 +1,413 @@
+package canvas
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/guregu/null/v5"
+)
+
+// ErrGraphQL is wrapped by errors reported in the "errors" field of a Canvas GraphQL response.
+var ErrGraphQL = errors.New("graphql query failed")
+
+// getGraphQLUrl derives the Canvas GraphQL endpoint from the REST base url,
+// e.g. https://<canvas>/api/v1 becomes https://<canvas>/api/graphql.
+func getGraphQLUrl(baseUrl string) string {
+	trimmed := strings.TrimSuffix(baseUrl, "/")
+
+	if strings.HasSuffix(trimmed, "/api/v1") {
+		return strings.TrimSuffix(trimmed, "/v1") + "/graphql"
+	}
+
+	return trimmed + "/api/graphql"
+}
+
+// graphql runs the query against Canvas GraphQL endpoint and decodes the "data" field into result.
+// Resource names the queried data in errors, e.g. "student report of user: 1".
+func (c *CanvasClient) graphql(ctx context.Context, query string, variables map[string]any, result any, resource string) error {
+	payload, err := json.Marshal(map[string]any{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return &Error{Resource: resource, URL: c.graphqlUrl, Err: err}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.graphqlUrl, bytes.NewReader(payload))
+	if err != nil {
+		return &Error{Resource: resource, URL: c.graphqlUrl, Err: err}
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.do(req)
+	if err != nil {
+		return &Error{Resource: resource, URL: c.graphqlUrl, Err: err}
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return newResponseError(res, resource)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return &Error{Resource: resource, URL: c.graphqlUrl, Err: err}
+	}
+
+	var response struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return &Error{Resource: resource, URL: c.graphqlUrl, Err: fmt.Errorf("error decoding response: %w", err)}
+	}
+
+	if len(response.Errors) > 0 {
+		e := &Error{
+			Resource:  resource,
+			URL:       c.graphqlUrl,
+			RequestID: res.Header.Get("X-Request-Context-Id"),
+			Err:       ErrGraphQL,
+		}
+
+		for _, item := range response.Errors {
+			e.Messages = append(e.Messages, item.Message)
+		}
+
+		return e
+	}
+
+	if err := json.Unmarshal(response.Data, result); err != nil {
+		return &Error{Resource: resource, URL: c.graphqlUrl, Err: fmt.Errorf("error decoding response: %w", err)}
+	}
+
+	return nil
+}
+
+// StudentReport is a user along with every enrollment of the user, as returned by a single GraphQL query.
+type StudentReport struct {
+	UserID      int                        `json:"user_id"`
+	Name        string                     `json:"name"`
+	SISUserID   null.String                `json:"sis_user_id"`
+	Enrollments []*StudentEnrollmentReport `json:"enrollments"`
+}
+
+// StudentEnrollmentReport is a user's enrollment along with the course, section, grades
+// and every submission of the user in the course, as returned by a single GraphQL query.
+type StudentEnrollmentReport struct {
+	EnrollmentID    int                       `json:"enrollment_id"`
+	EnrollmentState string                    `json:"enrollment_state"`
+	EnrollmentType  string                    `json:"enrollment_type"`
+	Grades          StudentReportGrades       `json:"grades"`
+	Course          StudentReportCourse       `json:"course"`
+	Section         StudentReportSection      `json:"section"`
+	Submissions     []StudentReportSubmission `json:"submissions"`
+}
+
+type StudentReportGrades struct {
+	HtmlUrl      string      `json:"html_url"`
+	CurrentScore null.Float  `json:"current_score"`
+	CurrentGrade null.String `json:"current_grade"`
+}
+
+type StudentReportCourse struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	State       string `json:"state"`
+	AccountName string `json:"account_name"`
+	Term        *Term  `json:"term"`
+}
+
+type StudentReportSection struct {
+	ID    int         `json:"id"`
+	Name  string      `json:"name"`
+	SISID null.String `json:"sis_id"`
+}
+
+type StudentReportSubmission struct {
+	AssignmentID   int         `json:"assignment_id"`
+	Title          string      `json:"title"`
+	PointsPossible null.Float  `json:"points_possible"`
+	DueAt          null.String `json:"due_at"`
+	Score          null.Float  `json:"score"`
+	SubmittedAt    null.String `json:"submitted_at"`
+	PostedAt       null.String `json:"posted_at"`
+	State          string      `json:"state"`
+	Late           bool        `json:"late"`
+	Missing        bool        `json:"missing"`
+}
+
+// Status returns the submission status in the same terms as the Canvas analytics API:
+// "missing", "late", "on_time" or "floating" for work that is neither submitted nor due.
+func (s StudentReportSubmission) Status() string {
+	switch {
+	case s.Missing:
+		return "missing"
+	case s.Late:
+		return "late"
+	case s.SubmittedAt.Valid:
+		return "on_time"
+	}
+
+	return "floating"
+}
+
+const studentReportQuery = `
+query StudentReport($userId: ID!) {
+  legacyNode(_id: $userId, type: User) {
+    ... on User {
+      _id
+      name
+      sisId
+      enrollments {
+        _id
+        state
+        type
+        grades { currentScore currentGrade htmlUrl }
+        section { _id name sisId }
+        course {
+          _id
+          name
+          state
+          account { name }
+          term { _id name startAt endAt }
+          submissionsConnection(studentIds: [$userId]) {
+            nodes { ...ReportSubmission }
+            pageInfo { hasNextPage endCursor }
+          }
+        }
+      }
+    }
+  }
+}
+` + reportSubmissionFragment
+
+const courseSubmissionsQuery = `
+query CourseSubmissions($courseId: ID!, $userId: ID!, $after: String) {
+  legacyNode(_id: $courseId, type: Course) {
+    ... on Course {
+      submissionsConnection(studentIds: [$userId], after: $after) {
+        nodes { ...ReportSubmission }
+        pageInfo { hasNextPage endCursor }
+      }
+    }
+  }
+}
+` + reportSubmissionFragment
+
+const reportSubmissionFragment = `
+fragment ReportSubmission on Submission {
+  score
+  submittedAt
+  postedAt
+  state
+  late
+  missing
+  assignment { _id name pointsPossible dueAt }
+}
+`
+
+type graphqlSubmission struct {
+	Score       null.Float  `json:"score"`
+	SubmittedAt null.String `json:"submittedAt"`
+	PostedAt    null.String `json:"postedAt"`
+	State       string      `json:"state"`
+	Late        bool        `json:"late"`
+	Missing     bool        `json:"missing"`
+	Assignment  struct {
+		ID             string      `json:"_id"`
+		Name           string      `json:"name"`
+		PointsPossible null.Float  `json:"pointsPossible"`
+		DueAt          null.String `json:"dueAt"`
+	} `json:"assignment"`
+}
+
+type graphqlSubmissionConnection struct {
+	Nodes    []graphqlSubmission `json:"nodes"`
+	PageInfo struct {
+		HasNextPage bool   `json:"hasNextPage"`
+		EndCursor   string `json:"endCursor"`
+	} `json:"pageInfo"`
+}
+
+// GetStudentReportByUserID retrieves the given user and every enrollment of the user with the course,
+// section, grades and the user's submissions in one GraphQL query.
+// Further queries are made only for courses with more submissions than fit in one connection page.
+func (c *CanvasClient) GetStudentReportByUserID(ctx context.Context, userID int) (*StudentReport, error) {
+	resource := fmt.Sprintf("student report of user: %d", userID)
+
+	var data struct {
+		LegacyNode *struct {
+			ID          string      `json:"_id"`
+			Name        string      `json:"name"`
+			SISID       null.String `json:"sisId"`
+			Enrollments []struct {
+				ID     string `json:"_id"`
+				State  string `json:"state"`
+				Type   string `json:"type"`
+				Grades *struct {
+					CurrentScore null.Float  `json:"currentScore"`
+					CurrentGrade null.String `json:"currentGrade"`
+					HtmlUrl      string      `json:"htmlUrl"`
+				} `json:"grades"`
+				Section *struct {
+					ID    string      `json:"_id"`
+					Name  string      `json:"name"`
+					SISID null.String `json:"sisId"`
+				} `json:"section"`
+				Course struct {
+					ID      string `json:"_id"`
+					Name    string `json:"name"`
+					State   string `json:"state"`
+					Account *struct {
+						Name string `json:"name"`
+					} `json:"account"`
+					Term *struct {
+						ID      string    `json:"_id"`
+						Name    string    `json:"name"`
+						StartAt null.Time `json:"startAt"`
+						EndAt   null.Time `json:"endAt"`
+					} `json:"term"`
+					SubmissionsConnection graphqlSubmissionConnection `json:"submissionsConnection"`
+				} `json:"course"`
+			} `json:"enrollments"`
+		} `json:"legacyNode"`
+	}
+
+	variables := map[string]any{"userId": strconv.Itoa(userID)}
+
+	if err := c.graphql(ctx, studentReportQuery, variables, &data, resource); err != nil {
+		return nil, err
+	}
+
+	if data.LegacyNode == nil {
+		return nil, &Error{Resource: resource, URL: c.graphqlUrl, StatusCode: http.StatusNotFound, Messages: []string{"user not found"}}
+	}
+
+	report := &StudentReport{
+		UserID:      atoi(data.LegacyNode.ID),
+		Name:        data.LegacyNode.Name,
+		SISUserID:   data.LegacyNode.SISID,
+		Enrollments: make([]*StudentEnrollmentReport, 0, len(data.LegacyNode.Enrollments)),
+	}
+
+	for _, enrollment := range data.LegacyNode.Enrollments {
+		result := &StudentEnrollmentReport{
+			EnrollmentID:    atoi(enrollment.ID),
+			EnrollmentState: enrollment.State,
+			EnrollmentType:  enrollment.Type,
+			Course: StudentReportCourse{
+				ID:    atoi(enrollment.Course.ID),
+				Name:  enrollment.Course.Name,
+				State: enrollment.Course.State,
+			},
+		}
+
+		if enrollment.Grades != nil {
+			result.Grades = StudentReportGrades{
+				HtmlUrl:      enrollment.Grades.HtmlUrl,
+				CurrentScore: enrollment.Grades.CurrentScore,
+				CurrentGrade: enrollment.Grades.CurrentGrade,
+			}
+		}
+
+		if enrollment.Section != nil {
+			result.Section = StudentReportSection{
+				ID:    atoi(enrollment.Section.ID),
+				Name:  enrollment.Section.Name,
+				SISID: enrollment.Section.SISID,
+			}
+		}
+
+		if enrollment.Course.Account != nil {
+			result.Course.AccountName = enrollment.Course.Account.Name
+		}
+
+		if term := enrollment.Course.Term; term != nil {
+			result.Course.Term = &Term{
+				ID:      atoi(term.ID),
+				Name:    term.Name,
+				StartAt: term.StartAt,
+				EndAt:   term.EndAt,
+			}
+		}
+
+		connection := enrollment.Course.SubmissionsConnection
+
+		for {
+			for _, submission := range connection.Nodes {
+				result.Submissions = append(result.Submissions, StudentReportSubmission{
+					AssignmentID:   atoi(submission.Assignment.ID),
+					Title:          submission.Assignment.Name,
+					PointsPossible: submission.Assignment.PointsPossible,
+					DueAt:          submission.Assignment.DueAt,
+					Score:          submission.Score,
+					SubmittedAt:    submission.SubmittedAt,
+					PostedAt:       submission.PostedAt,
+					State:          submission.State,
+					Late:           submission.Late,
+					Missing:        submission.Missing,
+				})
+			}
+
+			if !connection.PageInfo.HasNextPage {
+				break
+			}
+
+			next, err := c.getCourseSubmissionsPage(ctx, result.Course.ID, userID, connection.PageInfo.EndCursor)
+			if err != nil {
+				return nil, err
+			}
+
+			connection = next
+		}
+
+		report.Enrollments = append(report.Enrollments, result)
+	}
+
+	return report, nil
+}
+
+// getCourseSubmissionsPage retrieves the page of the user's submissions in the course after the given cursor.
+func (c *CanvasClient) getCourseSubmissionsPage(ctx context.Context, courseID, userID int, after string) (graphqlSubmissionConnection, error) {
+	var data struct {
+		LegacyNode *struct {
+			SubmissionsConnection graphqlSubmissionConnection `json:"submissionsConnection"`
+		} `json:"legacyNode"`
+	}
+
+	variables := map[string]any{
+		"courseId": strconv.Itoa(courseID),
+		"userId":   strconv.Itoa(userID),
+		"after":    after,
+	}
+
+	resource := fmt.Sprintf("submissions of course: %d and student: %d", courseID, userID)
+
+	if err := c.graphql(ctx, courseSubmissionsQuery, variables, &data, resource); err != nil {
+		return graphqlSubmissionConnection{}, err
+	}
+
+	if data.LegacyNode == nil {
+		return graphqlSubmissionConnection{}, nil
+	}
+
+	return data.LegacyNode.SubmissionsConnection, nil
+}
+
+// atoi converts a GraphQL legacy ID to int, returning 0 for malformed IDs.
+func atoi(id string) int {
+	value, _ := strconv.Atoi(id)
+	return value
+}
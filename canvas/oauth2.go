@@ -0,0 +1,242 @@
+package canvas
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidGrant is wrapped by errors of Canvas rejecting an OAuth2 code or refresh token,
+// e.g. when the user revoked the access of the developer key.
+var ErrInvalidGrant = errors.New("oauth2 grant rejected")
+
+// tokenExpiryMargin renews access tokens this long before they expire,
+// so a token does not expire while a request is in flight.
+const tokenExpiryMargin = time.Minute
+
+// ReportScopes are the developer key scopes of the REST endpoints called by CanvasClient.
+// Scoped keys must also allow include parameters, and cannot use GraphQL,
+// so WithGraphQLReports needs an unscoped key.
+var ReportScopes = []string{
+	"url:GET|/api/v1/accounts/:id",
+	"url:GET|/api/v1/accounts/:account_id/courses",
+	"url:GET|/api/v1/accounts/:account_id/sub_accounts",
+	"url:GET|/api/v1/accounts/:account_id/terms",
+	"url:GET|/api/v1/courses/:id",
+	"url:GET|/api/v1/courses/:course_id/assignments",
+	"url:GET|/api/v1/courses/:course_id/analytics/users/:student_id/assignments",
+	"url:GET|/api/v1/courses/:course_id/sections",
+	"url:GET|/api/v1/courses/:course_id/students/submissions",
+	"url:GET|/api/v1/sections/:id",
+	"url:GET|/api/v1/sections/:section_id/enrollments",
+	"url:GET|/api/v1/sections/:section_id/students/submissions",
+	"url:GET|/api/v1/users/:id",
+	"url:GET|/api/v1/users/:user_id/courses",
+	"url:GET|/api/v1/users/:user_id/enrollments",
+	"url:GET|/api/v1/users/:user_id/missing_submissions",
+}
+
+// TokenSource supplies the access token of Canvas requests.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// staticTokenSource is a long-lived access token, e.g. an admin token generated in Canvas settings.
+type staticTokenSource string
+
+func (s staticTokenSource) Token(ctx context.Context) (string, error) {
+	return string(s), nil
+}
+
+// tokenRenewer is a TokenSource that can replace an access token Canvas rejected before its expiry time.
+type tokenRenewer interface {
+	renew(ctx context.Context, rejected string) (string, error)
+}
+
+// OAuth2Token is a token issued to a Canvas developer key.
+type OAuth2Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// OAuth2Config is a Canvas developer key used with the OAuth2 authorization code flow.
+type OAuth2Config struct {
+	WebUrl       string // Canvas web url, e.g. https://school.instructure.com
+	ClientID     string
+	ClientSecret string
+	RedirectUrl  string
+	Scopes       []string // ReportScopes when empty
+}
+
+// AuthCodeURL returns the Canvas url asking the user to authorize the developer key.
+// Canvas redirects back to RedirectUrl with the code to pass to Exchange, and the given state.
+func (c *OAuth2Config) AuthCodeURL(state string) string {
+	scopes := c.Scopes
+	if len(scopes) == 0 {
+		scopes = ReportScopes
+	}
+
+	params := url.Values{}
+
+	params.Set("client_id", c.ClientID)
+	params.Set("response_type", "code")
+	params.Set("redirect_uri", c.RedirectUrl)
+	params.Set("state", state)
+	params.Set("scope", strings.Join(scopes, " "))
+
+	return fmt.Sprintf("%s/login/oauth2/auth?%s", strings.TrimRight(c.WebUrl, "/"), params.Encode())
+}
+
+// Exchange trades the code of the authorization redirect for a token.
+func (c *OAuth2Config) Exchange(ctx context.Context, code string) (*OAuth2Token, error) {
+	params := url.Values{}
+
+	params.Set("grant_type", "authorization_code")
+	params.Set("code", code)
+	params.Set("redirect_uri", c.RedirectUrl)
+
+	return c.requestToken(ctx, params)
+}
+
+// Refresh issues a new access token. Canvas keeps refresh tokens, so the given one is returned with it.
+func (c *OAuth2Config) Refresh(ctx context.Context, refreshToken string) (*OAuth2Token, error) {
+	params := url.Values{}
+
+	params.Set("grant_type", "refresh_token")
+	params.Set("refresh_token", refreshToken)
+
+	token, err := c.requestToken(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+
+	return token, nil
+}
+
+func (c *OAuth2Config) requestToken(ctx context.Context, params url.Values) (*OAuth2Token, error) {
+	tokenUrl := strings.TrimRight(c.WebUrl, "/") + "/login/oauth2/token"
+
+	params.Set("client_id", c.ClientID)
+	params.Set("client_secret", c.ClientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenUrl, strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{Timeout: time.Second * 10}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting oauth2 token: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxErrorBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("error reading oauth2 token: %w", err)
+	}
+
+	var payload struct {
+		AccessToken      string `json:"access_token"`
+		RefreshToken     string `json:"refresh_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+
+	// error bodies are decoded on a best effort basis, the status code decides
+	json.Unmarshal(body, &payload)
+
+	switch {
+	case res.StatusCode == http.StatusBadRequest || res.StatusCode == http.StatusUnauthorized:
+		return nil, fmt.Errorf("%w: %s: %s", ErrInvalidGrant, payload.Error, payload.ErrorDescription)
+	case res.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("error requesting oauth2 token: status %d", res.StatusCode)
+	case payload.AccessToken == "":
+		return nil, fmt.Errorf("error requesting oauth2 token: missing access token")
+	}
+
+	token := &OAuth2Token{
+		AccessToken:  payload.AccessToken,
+		RefreshToken: payload.RefreshToken,
+	}
+
+	// tokens without expiry never need renewal
+	if payload.ExpiresIn > 0 {
+		token.ExpiresAt = time.Now().Add(time.Second * time.Duration(payload.ExpiresIn))
+	}
+
+	return token, nil
+}
+
+// TokenSource returns a TokenSource starting from token, renewing the access token with its refresh token
+// when it is about to expire or Canvas rejects it.
+func (c *OAuth2Config) TokenSource(token *OAuth2Token) TokenSource {
+	return &oauth2TokenSource{config: c, token: *token}
+}
+
+type oauth2TokenSource struct {
+	config *OAuth2Config
+
+	mu    sync.Mutex
+	token OAuth2Token
+}
+
+// Token returns the current access token, renewing it first when it is about to expire.
+// Concurrent callers wait for a single renewal.
+func (s *oauth2TokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expired := s.token.AccessToken == "" ||
+		!s.token.ExpiresAt.IsZero() && time.Now().Add(tokenExpiryMargin).After(s.token.ExpiresAt)
+
+	if !expired {
+		return s.token.AccessToken, nil
+	}
+
+	return s.refresh(ctx)
+}
+
+// renew replaces the rejected access token, unless another request already did.
+func (s *oauth2TokenSource) renew(ctx context.Context, rejected string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token.AccessToken != rejected {
+		return s.token.AccessToken, nil
+	}
+
+	return s.refresh(ctx)
+}
+
+// refresh must be called with s.mu held.
+func (s *oauth2TokenSource) refresh(ctx context.Context) (string, error) {
+	if s.token.RefreshToken == "" {
+		return "", fmt.Errorf("%w: missing refresh token", ErrInvalidGrant)
+	}
+
+	token, err := s.config.Refresh(ctx, s.token.RefreshToken)
+	if err != nil {
+		return "", err
+	}
+
+	s.token = *token
+
+	return token.AccessToken, nil
+}
@@ -0,0 +1,184 @@
+package canvas_test
+
+import (
+	"canvas-report/canvas"
+	"canvas-report/canvas/canvastest"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTokenServer starts a Canvas OAuth2 token endpoint issuing accessToken for the refresh token "refresh",
+// and rejecting other refresh tokens. It returns the developer key and the number of tokens issued.
+func newTokenServer(t *testing.T, accessToken string) (*canvas.OAuth2Config, *atomic.Int32) {
+	var issued atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/login/oauth2/token" || r.PostFormValue("grant_type") != "refresh_token" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.PostFormValue("refresh_token") != "refresh" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant", "error_description": "refresh_token not found"})
+			return
+		}
+
+		// slow enough for concurrent callers to pile up
+		time.Sleep(time.Millisecond * 10)
+
+		issued.Add(1)
+
+		json.NewEncoder(w).Encode(map[string]any{"access_token": accessToken, "expires_in": 3600})
+	}))
+	t.Cleanup(srv.Close)
+
+	config := &canvas.OAuth2Config{WebUrl: srv.URL, ClientID: "client", ClientSecret: "secret"}
+
+	return config, &issued
+}
+
+func TestOAuth2TokenSourceRefreshesBeforeExpiry(t *testing.T) {
+	tests := []struct {
+		name       string
+		expiresAt  time.Time
+		want       string
+		wantIssued int32
+	}{
+		{name: "fresh", expiresAt: time.Now().Add(time.Hour), want: "current"},
+		{name: "about to expire", expiresAt: time.Now().Add(time.Second * 30), want: "renewed", wantIssued: 1},
+		{name: "expired", expiresAt: time.Now().Add(-time.Second), want: "renewed", wantIssued: 1},
+		{name: "without expiry", want: "current"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, issued := newTokenServer(t, "renewed")
+
+			tokens := config.TokenSource(&canvas.OAuth2Token{AccessToken: "current", RefreshToken: "refresh", ExpiresAt: tt.expiresAt})
+
+			token, err := tokens.Token(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if token != tt.want {
+				t.Errorf("got token %q, want %q", token, tt.want)
+			}
+
+			// the renewed token is valid for an hour
+			if _, err := tokens.Token(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := issued.Load(); got != tt.wantIssued {
+				t.Errorf("got %d tokens issued, want %d", got, tt.wantIssued)
+			}
+		})
+	}
+}
+
+func TestOAuth2TokenSourceRenewsOnceForConcurrentCallers(t *testing.T) {
+	config, issued := newTokenServer(t, "renewed")
+
+	tokens := config.TokenSource(&canvas.OAuth2Token{RefreshToken: "refresh"})
+
+	var wg sync.WaitGroup
+
+	got := make([]string, 20)
+	errs := make([]error, 20)
+
+	for i := range got {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			got[i], errs[i] = tokens.Token(context.Background())
+		}()
+	}
+
+	wg.Wait()
+
+	for i := range got {
+		if errs[i] != nil || got[i] != "renewed" {
+			t.Errorf("got token %q, error %v, want the renewed token", got[i], errs[i])
+		}
+	}
+
+	if n := issued.Load(); n != 1 {
+		t.Errorf("got %d tokens issued, want 1", n)
+	}
+}
+
+func TestOAuth2TokenSourceRenewsRejectedToken(t *testing.T) {
+	srv := canvastest.NewServer(canvastest.Fixtures{
+		Courses: []canvas.Course{{ID: 1, Name: "Math"}},
+	})
+	defer srv.Close()
+
+	config, issued := newTokenServer(t, canvastest.Token)
+
+	// revoked before its expiry, so Canvas rejects it with 401 Unauthorized
+	tokens := config.TokenSource(&canvas.OAuth2Token{AccessToken: "revoked", RefreshToken: "refresh", ExpiresAt: time.Now().Add(time.Hour)})
+
+	client, err := canvas.NewCanvasClientWithTokenSource(srv.BaseUrl(), tokens, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+
+	for range 5 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if _, err := client.GetCourseByID(context.Background(), 1); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if n := issued.Load(); n != 1 {
+		t.Errorf("got %d tokens issued, want the rejected token renewed once", n)
+	}
+}
+
+func TestOAuth2TokenSourceRejectedRefreshToken(t *testing.T) {
+	srv := canvastest.NewServer(canvastest.Fixtures{
+		Courses: []canvas.Course{{ID: 1, Name: "Math"}},
+	})
+	defer srv.Close()
+
+	config, _ := newTokenServer(t, canvastest.Token)
+
+	tokens := config.TokenSource(&canvas.OAuth2Token{RefreshToken: "revoked"})
+
+	client, err := canvas.NewCanvasClientWithTokenSource(srv.BaseUrl(), tokens, 10, canvas.WithRetryPolicy(canvas.RetryPolicy{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.GetCourseByID(context.Background(), 1)
+
+	if !errors.Is(err, canvas.ErrInvalidGrant) {
+		t.Errorf("got error %v, want ErrInvalidGrant", err)
+	}
+
+	if canvas.IsUnauthorized(err) {
+		t.Errorf("got error %v classified as unauthorized", err)
+	}
+}
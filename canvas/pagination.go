@@ -0,0 +1,301 @@
+package canvas
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrMaxPagesExceeded is returned when a listing has more pages than the client is allowed to fetch.
+var ErrMaxPagesExceeded = errors.New("maximum number of pages exceeded")
+
+// defaultPageConcurrency is the number of pages fetched concurrently when the last page is known.
+const defaultPageConcurrency = 4
+
+// WithPageConcurrency sets how many pages of a listing are fetched concurrently when Canvas reports
+// the last page number, 1 fetches pages one by one.
+func WithPageConcurrency(concurrency int) ClientOption {
+	return func(o *clientOptions) {
+		o.pageConcurrency = concurrency
+	}
+}
+
+// WithMaxPages limits the number of pages fetched for a single listing, 0 means no limit.
+func WithMaxPages(maxPages int) ClientOption {
+	return func(o *clientOptions) {
+		o.maxPages = maxPages
+	}
+}
+
+var linkRegEx = regexp.MustCompile(`^<(.*)>;\s*rel="([^"]+)"$`)
+
+// parseLinkHeader extracts the urls from the Link header string by their relation: "next", "last", etc.
+//
+// Canvas API provides pagination information in the Link header as comma separated string:
+// Link:
+// <https://<canvas>/api/v1/courses/:id/discussion_topics.json?opaqueA>; rel="current",
+// <https://<canvas>/api/v1/courses/:id/discussion_topics.json?opaqueB>; rel="next",
+// <https://<canvas>/api/v1/courses/:id/discussion_topics.json?opaqueC>; rel="first",
+// <https://<canvas>/api/v1/courses/:id/discussion_topics.json?opaqueD>; rel="last"
+func parseLinkHeader(linkHeader string) map[string]string {
+	result := make(map[string]string)
+
+	if linkHeader == "" {
+		return result
+	}
+
+	for _, link := range strings.Split(linkHeader, ",") {
+		matches := linkRegEx.FindStringSubmatch(strings.TrimSpace(link))
+		if matches == nil {
+			continue
+		}
+
+		result[matches[2]] = matches[1]
+	}
+
+	return result
+}
+
+// nextPageUrl returns the url of the page after requestUrl, or empty string if requestUrl is the last page.
+func nextPageUrl(requestUrl string, links map[string]string) string {
+	next := links["next"]
+
+	if next == "" || next == requestUrl {
+		return ""
+	}
+
+	if last, ok := links["last"]; ok && (last == requestUrl || links["current"] == last) {
+		return ""
+	}
+
+	return next
+}
+
+// paginate returns an iterator over every item of a Canvas listing starting at requestUrl.
+// Pages are fetched as the iterator is consumed and no further pages are requested once the caller stops.
+//
+// When the first page links to a numbered last page, the remaining pages are prefetched
+// concurrently, at most pageConcurrency ahead of the caller. Otherwise pages are walked one by one.
+// Either way items are yielded in page order.
+// Resource names the listing in errors, e.g. "courses of account: 1".
+func paginate[T any](ctx context.Context, c *CanvasClient, requestUrl, resource string) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		for page := 1; requestUrl != ""; page++ {
+			select {
+			case <-ctx.Done():
+				yield(nil, &Error{Resource: resource, URL: requestUrl, Err: ctx.Err()})
+				return
+			default:
+			}
+
+			if c.maxPages > 0 && page > c.maxPages {
+				yield(nil, &Error{Resource: resource, URL: requestUrl, Err: ErrMaxPagesExceeded})
+				return
+			}
+
+			items, links, err := getPage[T](ctx, c, requestUrl, resource)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if page == 1 && c.pageConcurrency > 1 {
+				if urls := numberedPageUrls(links); len(urls) > 0 {
+					if c.maxPages > 0 && len(urls)+1 > c.maxPages {
+						yield(nil, &Error{Resource: resource, URL: requestUrl, Err: ErrMaxPagesExceeded})
+						return
+					}
+
+					prefetch[T](ctx, c, urls, resource, yield)
+					return
+				}
+			}
+
+			requestUrl = nextPageUrl(requestUrl, links)
+		}
+	}
+}
+
+// numberedPageUrls returns the urls of every page from "next" up to "last",
+// or nil when Canvas does not report a numbered last page, e.g. for bookmark based pagination.
+func numberedPageUrls(links map[string]string) []string {
+	nextUrl, err := url.Parse(links["next"])
+	if err != nil || links["next"] == "" {
+		return nil
+	}
+
+	lastUrl, err := url.Parse(links["last"])
+	if err != nil || links["last"] == "" {
+		return nil
+	}
+
+	next, err := strconv.Atoi(nextUrl.Query().Get("page"))
+	if err != nil {
+		return nil
+	}
+
+	last, err := strconv.Atoi(lastUrl.Query().Get("page"))
+	if err != nil || last < next {
+		return nil
+	}
+
+	urls := make([]string, 0, last-next+1)
+
+	for page := next; page <= last; page++ {
+		params := nextUrl.Query()
+		params.Set("page", strconv.Itoa(page))
+
+		pageUrl := *nextUrl
+		pageUrl.RawQuery = params.Encode()
+
+		urls = append(urls, pageUrl.String())
+	}
+
+	return urls
+}
+
+type pageResult[T any] struct {
+	items []*T
+	err   error
+}
+
+// prefetch fetches the pages concurrently and yields their items in page order.
+// At most pageConcurrency pages are in flight or waiting to be consumed, so memory stays bounded
+// when the caller is slower than Canvas.
+func prefetch[T any](ctx context.Context, c *CanvasClient, urls []string, resource string, yield func(*T, error) bool) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pending := make([]chan pageResult[T], len(urls))
+
+	start := func(i int) {
+		pending[i] = make(chan pageResult[T], 1)
+
+		go func() {
+			items, _, err := getPage[T](ctx, c, urls[i], resource)
+			pending[i] <- pageResult[T]{items, err}
+		}()
+	}
+
+	for i := range min(c.pageConcurrency, len(urls)) {
+		start(i)
+	}
+
+	for i := range urls {
+		var result pageResult[T]
+
+		select {
+		case <-ctx.Done():
+			yield(nil, &Error{Resource: resource, URL: urls[i], Err: ctx.Err()})
+			return
+		case result = <-pending[i]:
+		}
+
+		if result.err != nil {
+			yield(nil, result.err)
+			return
+		}
+
+		if next := i + c.pageConcurrency; next < len(urls) {
+			start(next)
+		}
+
+		for _, item := range result.items {
+			if !yield(item, nil) {
+				return
+			}
+		}
+	}
+}
+
+// failed returns an iterator that yields only the given error.
+func failed[T any](err error) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		yield(nil, err)
+	}
+}
+
+// collect consumes the iterator into a slice.
+func collect[T any](seq iter.Seq2[*T, error]) ([]*T, error) {
+	result := make([]*T, 0)
+
+	for item, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, item)
+	}
+
+	return result, nil
+}
+
+// getPage retrieves a single page of a Canvas listing along with its Link header relations.
+func getPage[T any](ctx context.Context, c *CanvasClient, requestUrl, resource string) ([]*T, map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestUrl, nil)
+	if err != nil {
+		return nil, nil, &Error{Resource: resource, URL: requestUrl, Err: err}
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		return nil, nil, &Error{Resource: resource, URL: requestUrl, Err: err}
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, nil, newResponseError(res, resource)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, &Error{Resource: resource, URL: requestUrl, Err: err}
+	}
+
+	items, err := decodePage[T](body)
+	if err != nil {
+		return nil, nil, &Error{Resource: resource, URL: requestUrl, Err: fmt.Errorf("error decoding response: %w", err)}
+	}
+
+	return items, parseLinkHeader(res.Header.Get("Link")), nil
+}
+
+// decodePage decodes the items of a page. Most listings are JSON arrays,
+// a few are wrapped in an object with a single array field, e.g. {"enrollment_terms": [...]}.
+func decodePage[T any](body []byte) ([]*T, error) {
+	var items []*T
+
+	if err := json.Unmarshal(body, &items); err == nil {
+		return items, nil
+	}
+
+	var envelope map[string][]*T
+
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, err
+	}
+
+	if len(envelope) != 1 {
+		return nil, fmt.Errorf("unexpected page with %d fields", len(envelope))
+	}
+
+	for _, items := range envelope {
+		return items, nil
+	}
+
+	return nil, nil
+}
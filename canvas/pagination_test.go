@@ -0,0 +1,389 @@
+package canvas
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParseLinkHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   map[string]string
+	}{
+		{
+			name:   "empty",
+			header: "",
+			want:   map[string]string{},
+		},
+		{
+			name: "numbered pages",
+			header: `<https://canvas.test/api/v1/courses?page=1&per_page=10>; rel="current",` +
+				`<https://canvas.test/api/v1/courses?page=2&per_page=10>; rel="next",` +
+				`<https://canvas.test/api/v1/courses?page=1&per_page=10>; rel="first",` +
+				`<https://canvas.test/api/v1/courses?page=5&per_page=10>; rel="last"`,
+			want: map[string]string{
+				"current": "https://canvas.test/api/v1/courses?page=1&per_page=10",
+				"next":    "https://canvas.test/api/v1/courses?page=2&per_page=10",
+				"first":   "https://canvas.test/api/v1/courses?page=1&per_page=10",
+				"last":    "https://canvas.test/api/v1/courses?page=5&per_page=10",
+			},
+		},
+		{
+			name:   "whitespace around links and relations",
+			header: "  <https://canvas.test/a?page=2>;rel=\"next\" ,\n\t<https://canvas.test/a?page=3>;   rel=\"last\"  ",
+			want: map[string]string{
+				"next": "https://canvas.test/a?page=2",
+				"last": "https://canvas.test/a?page=3",
+			},
+		},
+		{
+			name:   "no next on the last page",
+			header: `<https://canvas.test/a?page=3>; rel="current",<https://canvas.test/a?page=1>; rel="first",<https://canvas.test/a?page=3>; rel="last"`,
+			want: map[string]string{
+				"current": "https://canvas.test/a?page=3",
+				"first":   "https://canvas.test/a?page=1",
+				"last":    "https://canvas.test/a?page=3",
+			},
+		},
+		{
+			name:   "bookmark pages",
+			header: `<https://canvas.test/a?page=bookmark:WzEwXQ&per_page=10>; rel="current",<https://canvas.test/a?page=bookmark:WzIwXQ&per_page=10>; rel="next"`,
+			want: map[string]string{
+				"current": "https://canvas.test/a?page=bookmark:WzEwXQ&per_page=10",
+				"next":    "https://canvas.test/a?page=bookmark:WzIwXQ&per_page=10",
+			},
+		},
+		{
+			name:   "malformed links are skipped",
+			header: `https://canvas.test/a?page=2; rel="next",<https://canvas.test/a?page=3>; rel="last"`,
+			want: map[string]string{
+				"last": "https://canvas.test/a?page=3",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseLinkHeader(tt.header); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLinkHeader() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextPageUrl(t *testing.T) {
+	tests := []struct {
+		name       string
+		requestUrl string
+		links      map[string]string
+		want       string
+	}{
+		{
+			name:       "next page",
+			requestUrl: "https://canvas.test/a?page=1",
+			links:      map[string]string{"next": "https://canvas.test/a?page=2", "last": "https://canvas.test/a?page=3"},
+			want:       "https://canvas.test/a?page=2",
+		},
+		{
+			name:       "no next",
+			requestUrl: "https://canvas.test/a?page=3",
+			links:      map[string]string{"last": "https://canvas.test/a?page=3"},
+			want:       "",
+		},
+		{
+			name:       "current is last",
+			requestUrl: "https://canvas.test/a",
+			links:      map[string]string{"current": "https://canvas.test/a?page=3", "next": "https://canvas.test/a?page=4", "last": "https://canvas.test/a?page=3"},
+			want:       "",
+		},
+		{
+			name:       "request is last",
+			requestUrl: "https://canvas.test/a?page=3",
+			links:      map[string]string{"next": "https://canvas.test/a?page=4", "last": "https://canvas.test/a?page=3"},
+			want:       "",
+		},
+		{
+			name:       "next is the request",
+			requestUrl: "https://canvas.test/a?page=2",
+			links:      map[string]string{"next": "https://canvas.test/a?page=2"},
+			want:       "",
+		},
+		{
+			name:       "bookmark without last",
+			requestUrl: "https://canvas.test/a?page=bookmark:WzEwXQ",
+			links:      map[string]string{"current": "https://canvas.test/a?page=bookmark:WzEwXQ", "next": "https://canvas.test/a?page=bookmark:WzIwXQ"},
+			want:       "https://canvas.test/a?page=bookmark:WzIwXQ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextPageUrl(tt.requestUrl, tt.links); got != tt.want {
+				t.Errorf("nextPageUrl() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNumberedPageUrls(t *testing.T) {
+	tests := []struct {
+		name  string
+		links map[string]string
+		want  []string
+	}{
+		{
+			name:  "numbered",
+			links: map[string]string{"next": "https://canvas.test/a?page=2&per_page=10", "last": "https://canvas.test/a?page=4&per_page=10"},
+			want: []string{
+				"https://canvas.test/a?page=2&per_page=10",
+				"https://canvas.test/a?page=3&per_page=10",
+				"https://canvas.test/a?page=4&per_page=10",
+			},
+		},
+		{
+			name:  "bookmark",
+			links: map[string]string{"next": "https://canvas.test/a?page=bookmark:WzIwXQ", "last": "https://canvas.test/a?page=bookmark:WzkwXQ"},
+			want:  nil,
+		},
+		{
+			name:  "no last",
+			links: map[string]string{"next": "https://canvas.test/a?page=2"},
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := numberedPageUrls(tt.links); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("numberedPageUrls() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type testItem struct {
+	ID int `json:"id"`
+}
+
+func TestDecodePage(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    []int
+		wantErr bool
+	}{
+		{name: "array", body: `[{"id":1},{"id":2}]`, want: []int{1, 2}},
+		{name: "empty array", body: `[]`, want: []int{}},
+		{name: "enveloped", body: `{"enrollment_terms":[{"id":3},{"id":4}]}`, want: []int{3, 4}},
+		{name: "empty envelope", body: `{"enrollment_terms":[]}`, want: []int{}},
+		{name: "several fields", body: `{"enrollment_terms":[{"id":3}],"other":[]}`, wantErr: true},
+		{name: "object", body: `{"id":1}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := decodePage[testItem]([]byte(tt.body))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("decodePage() = %v, want error", items)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("decodePage() error = %v", err)
+			}
+
+			got := make([]int, 0, len(items))
+			for _, item := range items {
+				got = append(got, item.ID)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodePage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// newPagesServer serves items 1 to total, perPage at a time, with Link headers.
+// Numbered pages link to the last page, bookmark pages only link to the next one.
+// Pages of enveloped listings wrap the items in {"enrollment_terms": [...]}.
+func newPagesServer(t *testing.T, total, perPage int, bookmarks, enveloped bool) (*httptest.Server, *atomic.Int32) {
+	var requests atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		page := 1
+
+		if value := r.URL.Query().Get("page"); value != "" {
+			fmt.Sscanf(value, "bookmark:%d", &page)
+
+			if n, err := strconv.Atoi(value); err == nil {
+				page = n
+			}
+		}
+
+		pageUrl := func(p int) string {
+			if bookmarks {
+				return fmt.Sprintf("http://%s%s?page=bookmark:%d", r.Host, r.URL.Path, p)
+			}
+
+			return fmt.Sprintf("http://%s%s?page=%d", r.Host, r.URL.Path, p)
+		}
+
+		last := (total + perPage - 1) / perPage
+
+		link := fmt.Sprintf(`<%s>; rel="current"`, pageUrl(page))
+
+		if page < last {
+			link += fmt.Sprintf(`,<%s>; rel="next"`, pageUrl(page+1))
+		}
+
+		if !bookmarks {
+			link += fmt.Sprintf(`,<%s>; rel="last"`, pageUrl(last))
+		}
+
+		w.Header().Set("Link", link)
+
+		items := make([]testItem, 0)
+		for id := (page-1)*perPage + 1; id <= min(page*perPage, total); id++ {
+			items = append(items, testItem{ID: id})
+		}
+
+		if enveloped {
+			json.NewEncoder(w).Encode(map[string][]testItem{"enrollment_terms": items})
+			return
+		}
+
+		json.NewEncoder(w).Encode(items)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	return srv, &requests
+}
+
+func TestPaginate(t *testing.T) {
+	tests := []struct {
+		name         string
+		bookmarks    bool
+		enveloped    bool
+		opts         []ClientOption
+		wantIDs      int
+		wantErr      error
+		wantRequests int32
+	}{
+		{name: "numbered pages one by one", opts: []ClientOption{WithPageConcurrency(1)}, wantIDs: 25, wantRequests: 3},
+		{name: "numbered pages prefetched", opts: []ClientOption{WithPageConcurrency(4)}, wantIDs: 25, wantRequests: 3},
+		{name: "bookmark pages", bookmarks: true, wantIDs: 25, wantRequests: 3},
+		{name: "enveloped pages", enveloped: true, wantIDs: 25, wantRequests: 3},
+		{name: "max pages one by one", opts: []ClientOption{WithPageConcurrency(1), WithMaxPages(2)}, wantIDs: 20, wantErr: ErrMaxPagesExceeded, wantRequests: 2},
+		{name: "max pages prefetched", opts: []ClientOption{WithPageConcurrency(4), WithMaxPages(2)}, wantIDs: 10, wantErr: ErrMaxPagesExceeded, wantRequests: 1},
+		{name: "max pages of bookmarks", bookmarks: true, opts: []ClientOption{WithMaxPages(2)}, wantIDs: 20, wantErr: ErrMaxPagesExceeded, wantRequests: 2},
+		{name: "max pages not reached", opts: []ClientOption{WithMaxPages(3)}, wantIDs: 25, wantRequests: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := newPagesServer(t, 25, 10, tt.bookmarks, tt.enveloped)
+
+			client, err := NewCanvasClient(srv.URL, "token", 10, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ids := 0
+			var gotErr error
+
+			for item, err := range paginate[testItem](context.Background(), client, srv.URL+"/items", "items") {
+				if err != nil {
+					gotErr = err
+					break
+				}
+
+				ids++
+
+				if item.ID != ids {
+					t.Fatalf("item %d has id %d, want items in page order", ids, item.ID)
+				}
+			}
+
+			if ids != tt.wantIDs {
+				t.Errorf("got %d items, want %d", ids, tt.wantIDs)
+			}
+
+			if !errors.Is(gotErr, tt.wantErr) {
+				t.Errorf("got error %v, want %v", gotErr, tt.wantErr)
+			}
+
+			var canvasErr *Error
+			if tt.wantErr != nil && !errors.As(gotErr, &canvasErr) {
+				t.Errorf("got error %T, want *Error", gotErr)
+			}
+
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("got %d requests, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestPaginateStopsWhenCallerBreaks(t *testing.T) {
+	srv, requests := newPagesServer(t, 50, 10, true, false)
+
+	client, err := NewCanvasClient(srv.URL, "token", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := 0
+
+	for _, err := range paginate[testItem](context.Background(), client, srv.URL+"/items", "items") {
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ids++
+
+		// stop in the middle of the second page
+		if ids == 15 {
+			break
+		}
+	}
+
+	if got := requests.Load(); got != 2 {
+		t.Errorf("got %d requests, want 2", got)
+	}
+}
+
+func TestPaginateCanceledContext(t *testing.T) {
+	srv, requests := newPagesServer(t, 25, 10, true, false)
+
+	client, err := NewCanvasClient(srv.URL, "token", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = collect(paginate[testItem](ctx, client, srv.URL+"/items", "items"))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+
+	if got := requests.Load(); got != 0 {
+		t.Errorf("got %d requests, want 0", got)
+	}
+}
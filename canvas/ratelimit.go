@@ -0,0 +1,171 @@
+package canvas
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultRateLimitThreshold is the remaining quota below which requests are slowed down.
+	defaultRateLimitThreshold = 100
+
+	// rateLimitRefillPerSecond is a conservative estimate of how fast Canvas refills the quota.
+	rateLimitRefillPerSecond = 10
+
+	// maxRateLimitPause caps a single pause so a stale reading cannot stall the client for long.
+	maxRateLimitPause = time.Second * 30
+)
+
+// RateLimit is the Canvas request quota as last reported by Canvas.
+//
+// Canvas uses a leaky bucket per access token. Every response carries the
+// quota left in X-Rate-Limit-Remaining and the cost of the request in X-Request-Cost.
+type RateLimit struct {
+	Remaining float64   `json:"remaining"`
+	LastCost  float64   `json:"last_cost"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// rateLimiter tracks the remaining Canvas quota and pauses requests before Canvas throttles them.
+type rateLimiter struct {
+	mu        sync.Mutex
+	threshold float64
+	limit     RateLimit
+}
+
+func newRateLimiter(threshold float64) *rateLimiter {
+	return &rateLimiter{threshold: threshold}
+}
+
+// current returns the latest known quota.
+func (l *rateLimiter) current() RateLimit {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.limit
+}
+
+// delay returns how long a request should wait before being sent.
+// No delay is applied until Canvas has reported the quota at least once.
+func (l *rateLimiter) delay() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limit.UpdatedAt.IsZero() {
+		return 0
+	}
+
+	// quota refilled since the last reading
+	refilled := time.Since(l.limit.UpdatedAt).Seconds() * rateLimitRefillPerSecond
+	expected := l.limit.Remaining + refilled - l.limit.LastCost
+
+	if expected >= l.threshold {
+		return 0
+	}
+
+	pause := time.Duration((l.threshold - expected) / rateLimitRefillPerSecond * float64(time.Second))
+
+	return min(pause, maxRateLimitPause)
+}
+
+// wait blocks until the quota is expected to be above threshold or the context is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	pause := l.delay()
+	if pause <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(pause)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// update records the quota from response headers.
+func (l *rateLimiter) update(header http.Header) {
+	remaining, err := strconv.ParseFloat(header.Get("X-Rate-Limit-Remaining"), 64)
+	if err != nil {
+		return
+	}
+
+	cost, _ := strconv.ParseFloat(header.Get("X-Request-Cost"), 64)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limit = RateLimit{
+		Remaining: remaining,
+		LastCost:  cost,
+		UpdatedAt: time.Now(),
+	}
+}
+
+// exhaust marks the quota as used up after Canvas has throttled a request.
+func (l *rateLimiter) exhaust() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limit.Remaining = 0
+	l.limit.UpdatedAt = time.Now()
+}
+
+// rateLimitTransport is a custom RoundTripper that waits for Canvas quota before sending requests.
+type rateLimitTransport struct {
+	Transport http.RoundTripper
+	Limiter   *rateLimiter
+}
+
+// RoundTrip pauses the request when the quota is low and records the quota reported in the response.
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.Limiter.wait(req.Context()); err != nil {
+		return nil, err
+	}
+
+	res, err := t.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	t.Limiter.update(res.Header)
+
+	if isRateLimitExceeded(res) {
+		t.Limiter.exhaust()
+	}
+
+	return res, nil
+}
+
+// isRateLimitExceeded reports whether Canvas rejected the request because the quota ran out.
+// Canvas responds with 403 Forbidden and "Rate Limit Exceeded" in the body.
+// The body is restored so callers can still read it.
+func isRateLimitExceeded(res *http.Response) bool {
+	if res.StatusCode != http.StatusForbidden {
+		return false
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err != nil {
+		return false
+	}
+
+	return strings.Contains(string(body), "Rate Limit Exceeded")
+}
+
+// RateLimit returns the Canvas request quota as last reported by Canvas.
+func (c *CanvasClient) RateLimit() RateLimit {
+	return c.rateLimiter.current()
+}
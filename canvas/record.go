@@ -0,0 +1,365 @@
+package canvas
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Interaction is a recorded Canvas request and response, stored as one JSON file per interaction.
+// Only the path and query of the request url are kept, so fixtures replay against any base url.
+type Interaction struct {
+	Request struct {
+		Method   string `json:"method"`
+		Url      string `json:"url"`
+		BodyHash string `json:"body_hash,omitempty"` // tells apart GraphQL queries sent to the same url
+	} `json:"request"`
+	Response struct {
+		StatusCode int         `json:"status_code"`
+		Header     http.Header `json:"header"`
+		Body       string      `json:"body"`
+	} `json:"response"`
+}
+
+// scrubbedHeaders are never written to fixtures.
+var scrubbedHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+// scrubbedKeys are JSON keys holding personal data, in REST snake case and GraphQL camel case.
+// Their values are replaced with pseudonyms.
+var scrubbedKeys = []string{
+	"sortable_name", "short_name", "login_id", "email", "sis_user_id", "integration_id",
+	"intergration_id", "avatar_url", "display_name", "pronouns", "sis_login_id",
+	"sortableName", "shortName", "loginId", "sisId", "sisUserId", "integrationId",
+	"avatarUrl", "displayName", "sisLoginId",
+}
+
+// userKeys mark an object as a user, so its "name" is scrubbed too. Course and section names are kept.
+var userKeys = []string{"sortable_name", "short_name", "login_id", "sortableName", "shortName", "loginId"}
+
+// userParentKeys hold user objects. GraphQL queries fetch users as legacyNode(type: User),
+// nodes of other types are told apart by their "__typename" when the query asks for it.
+var userParentKeys = []string{"user", "users", "legacyNode"}
+
+// Recorder records Canvas interactions to fixture files, with access tokens and personal data scrubbed,
+// so report runs can be replayed offline with a Replayer. Use it with WithRecorder.
+type Recorder struct {
+	dir  string
+	salt []byte
+
+	mu    sync.Mutex
+	count int
+}
+
+// NewRecorder records interactions into dir.
+func NewRecorder(dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("error creating fixtures directory: %w", err)
+	}
+
+	// pseudonyms are salted per recording, so they cannot be reversed by hashing known names
+	salt := make([]byte, 16)
+
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	recorder := &Recorder{
+		dir:  dir,
+		salt: salt,
+	}
+
+	return recorder, nil
+}
+
+// WithRecorder records every request the client sends to Canvas, and its response, with recorder.
+// Clients sharing the recorder, e.g. those returned by WithAccessToken, record into the same fixtures.
+func WithRecorder(recorder *Recorder) ClientOption {
+	return func(o *clientOptions) {
+		o.recorder = recorder
+	}
+}
+
+// recordTransport is a custom RoundTripper that records the interactions sent through Transport.
+type recordTransport struct {
+	Transport http.RoundTripper
+	Recorder  *Recorder
+}
+
+// RoundTrip sends the request and writes the scrubbed interaction to a new fixture file.
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err := t.Recorder.record(req, res, body); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// record writes the interaction to a new fixture file.
+func (rec *Recorder) record(req *http.Request, res *http.Response, body []byte) error {
+	var interaction Interaction
+
+	interaction.Request.Method = req.Method
+	interaction.Request.Url = scrubUrl(req.URL)
+	interaction.Request.BodyHash = requestBodyHash(req)
+	interaction.Response.StatusCode = res.StatusCode
+	interaction.Response.Header = res.Header.Clone()
+	interaction.Response.Body = rec.scrubBody(body)
+
+	for _, name := range scrubbedHeaders {
+		interaction.Response.Header.Del(name)
+	}
+
+	// scrubbing changes the body length
+	interaction.Response.Header.Del("Content-Length")
+
+	// Link header urls carry the Canvas host, keep only path and query
+	if link := res.Header.Get("Link"); link != "" {
+		interaction.Response.Header.Set("Link", scrubLinkHeader(link))
+	}
+
+	data, err := json.MarshalIndent(interaction, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	rec.mu.Lock()
+	rec.count++
+	name := fmt.Sprintf("%04d-%s%s.json", rec.count, req.Method, fixtureName(req.URL.Path))
+	rec.mu.Unlock()
+
+	if err := os.WriteFile(filepath.Join(rec.dir, name), data, 0o600); err != nil {
+		return fmt.Errorf("error writing fixture: %w", err)
+	}
+
+	return nil
+}
+
+// scrubBody replaces personal data in JSON bodies with salted pseudonyms.
+// The same value always gets the same pseudonym within a recording, so relations between fixtures are kept.
+// Non JSON bodies are kept as they are.
+func (rec *Recorder) scrubBody(body []byte) string {
+	var value any
+
+	if err := json.Unmarshal(body, &value); err != nil {
+		return string(body)
+	}
+
+	data, err := json.Marshal(rec.scrubValue(value, false))
+	if err != nil {
+		return string(body)
+	}
+
+	return string(data)
+}
+
+func (rec *Recorder) scrubValue(value any, isUser bool) any {
+	switch v := value.(type) {
+	case []any:
+		for i := range v {
+			v[i] = rec.scrubValue(v[i], isUser)
+		}
+	case map[string]any:
+		isUser = isUser || slices.ContainsFunc(userKeys, func(key string) bool {
+			_, ok := v[key]
+			return ok
+		})
+
+		if typename, ok := v["__typename"].(string); ok {
+			isUser = typename == "User"
+		}
+
+		for key, item := range v {
+			switch {
+			case slices.Contains(scrubbedKeys, key), isUser && key == "name":
+				if s, ok := item.(string); ok && s != "" {
+					v[key] = rec.pseudonym(s)
+				}
+			default:
+				v[key] = rec.scrubValue(item, slices.Contains(userParentKeys, key))
+			}
+		}
+	}
+
+	return value
+}
+
+func (rec *Recorder) pseudonym(value string) string {
+	hash := sha256.Sum256([]byte(string(rec.salt) + value))
+
+	return "scrubbed-" + hex.EncodeToString(hash[:6])
+}
+
+// scrubUrl returns the path and query of the url without the access_token parameter.
+func scrubUrl(u *url.URL) string {
+	params := u.Query()
+	params.Del("access_token")
+
+	if len(params) == 0 {
+		return u.Path
+	}
+
+	return u.Path + "?" + params.Encode()
+}
+
+var linkUrlRegEx = regexp.MustCompile(`<([^>]*)>`)
+
+func scrubLinkHeader(link string) string {
+	return linkUrlRegEx.ReplaceAllStringFunc(link, func(match string) string {
+		u, err := url.Parse(match[1 : len(match)-1])
+		if err != nil {
+			return match
+		}
+
+		return "<" + scrubUrl(u) + ">"
+	})
+}
+
+// requestBodyHash returns a short hash of the request body, or empty string if there is none.
+func requestBodyHash(req *http.Request) string {
+	if req.GetBody == nil {
+		return ""
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil || len(data) == 0 {
+		return ""
+	}
+
+	hash := sha256.Sum256(data)
+
+	return hex.EncodeToString(hash[:8])
+}
+
+func interactionKey(method string, u *url.URL, bodyHash string) string {
+	return strings.TrimSpace(method + " " + scrubUrl(u) + " " + bodyHash)
+}
+
+var fixtureNameRegEx = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// fixtureName makes a readable file name part from the request path, e.g. "-courses-1-assignments".
+func fixtureName(path string) string {
+	name := fixtureNameRegEx.ReplaceAllString(strings.TrimPrefix(path, "/api/v1"), "-")
+
+	return strings.TrimRight(name, "-")
+}
+
+// Replayer is a RoundTripper serving responses from fixture files written by Recorder,
+// to be used as the client transport with WithTransport.
+// Requests are matched by method, path and query. Repeated requests get the recorded responses in order,
+// the last one being served again once they run out.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions map[string][]*Interaction
+}
+
+// NewReplayer loads every fixture file in dir.
+func NewReplayer(dir string) (*Replayer, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	// fixture names start with the recording sequence number
+	slices.Sort(files)
+
+	replayer := &Replayer{
+		interactions: make(map[string][]*Interaction),
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading fixture: %w", err)
+		}
+
+		var interaction Interaction
+
+		if err := json.Unmarshal(data, &interaction); err != nil {
+			return nil, fmt.Errorf("error decoding fixture %s: %w", filepath.Base(file), err)
+		}
+
+		u, err := url.Parse(interaction.Request.Url)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing fixture %s url: %w", filepath.Base(file), err)
+		}
+
+		key := interactionKey(interaction.Request.Method, u, interaction.Request.BodyHash)
+		replayer.interactions[key] = append(replayer.interactions[key], &interaction)
+	}
+
+	return replayer, nil
+}
+
+// RoundTrip serves the recorded response of the request. Link header urls are pointed back at the request host.
+func (rep *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := interactionKey(req.Method, req.URL, requestBodyHash(req))
+
+	rep.mu.Lock()
+
+	recorded, ok := rep.interactions[key]
+	if !ok {
+		rep.mu.Unlock()
+		return nil, fmt.Errorf("no recorded response for %s", key)
+	}
+
+	interaction := recorded[0]
+
+	if len(recorded) > 1 {
+		rep.interactions[key] = recorded[1:]
+	}
+
+	rep.mu.Unlock()
+
+	header := interaction.Response.Header.Clone()
+
+	if link := header.Get("Link"); link != "" {
+		origin := req.URL.Scheme + "://" + req.URL.Host
+
+		header.Set("Link", linkUrlRegEx.ReplaceAllString(link, "<"+origin+"$1>"))
+	}
+
+	res := &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+		StatusCode:    interaction.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+		ContentLength: int64(len(interaction.Response.Body)),
+		Request:       req,
+	}
+
+	return res, nil
+}
@@ -0,0 +1,162 @@
+package canvas_test
+
+import (
+	"canvas-report/canvas"
+	"canvas-report/canvas/canvastest"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const studentReportResponse = `{"data":{"legacyNode":{
+	"_id":"1000","name":"Ada Lovelace","sisId":"SIS-ADA-1815","sortableName":"Lovelace, Ada","loginId":"ada@example.edu","email":"ada@example.edu",
+	"enrollments":[{"_id":"1","state":"active","type":"StudentEnrollment",
+		"grades":{"currentScore":91.5,"currentGrade":"A","htmlUrl":"https://canvas.test/courses/100/grades/1000"},
+		"section":{"_id":"200","name":"Section A","sisId":"SEC-ADA-1815"},
+		"course":{"_id":"100","name":"Math","state":"available","account":{"name":"Science"},"term":null,
+			"submissionsConnection":{"nodes":[],"pageInfo":{"hasNextPage":false,"endCursor":""}}}}]}}}`
+
+func TestRecorderScrubsPersonalData(t *testing.T) {
+	graphql := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(studentReportResponse))
+	}))
+	defer graphql.Close()
+
+	srv := canvastest.NewServer(canvastest.Fixtures{
+		Accounts: []canvas.Account{{ID: 1, Name: "Science"}},
+		Courses:  []canvas.Course{{ID: 100, Name: "Math", AccountID: 1}},
+		Sections: []canvas.Section{{ID: 200, CourseID: 100, Name: "Section A"}},
+		Users: []canvas.User{{
+			ID:           1001,
+			Name:         "Grace Hopper",
+			SortableName: "Hopper, Grace",
+			ShortName:    "Grace",
+			SISUserID:    "SIS-GRACE-1906",
+			LoginID:      "grace@example.edu",
+		}},
+		Enrollments: []canvas.Enrollment{
+			{ID: 2, UserID: 1001, CourseID: 100, CourseSectionID: 200, Type: "StudentEnrollment", EnrollmentState: "active"},
+		},
+	})
+	defer srv.Close()
+
+	dir := t.TempDir()
+
+	recorder, err := canvas.NewRecorder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	graphqlClient, err := canvas.NewCanvasClient(graphql.URL+"/api/v1", canvastest.Token, 10, canvas.WithRecorder(recorder))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := graphqlClient.GetStudentReportByUserID(ctx, 1000); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := srv.Client(canvas.WithRecorder(recorder))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.GetEnrollmentsBySectionID(ctx, 200, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.GetUserByID(ctx, 1001); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 3 {
+		t.Fatalf("got %d fixtures, want 3", len(files))
+	}
+
+	var fixtures strings.Builder
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fixtures.Write(data)
+	}
+
+	personal := []string{
+		"Ada", "Lovelace", "SIS-ADA-1815", "SEC-ADA-1815", "ada@example.edu",
+		"Grace", "Hopper", "SIS-GRACE-1906", "grace@example.edu",
+		canvastest.Token,
+	}
+
+	for _, value := range personal {
+		if strings.Contains(fixtures.String(), value) {
+			t.Errorf("fixtures contain %q", value)
+		}
+	}
+
+	// names of courses and sections are not personal data
+	for _, value := range []string{"Math", "Section A", "Science"} {
+		if !strings.Contains(fixtures.String(), value) {
+			t.Errorf("fixtures lost %q", value)
+		}
+	}
+}
+
+func TestReplayerServesRecordedInteractions(t *testing.T) {
+	srv := newCoursesServer(t, 15)
+
+	dir := t.TempDir()
+
+	recorder, err := canvas.NewRecorder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := srv.Client(canvas.WithRecorder(recorder))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	recorded, err := client.GetCoursesByAccountID(ctx, 1, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replayer, err := canvas.NewReplayer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the recorded pages are served back without the fake
+	srv.Close()
+
+	replay, err := canvas.NewCanvasClient(srv.BaseUrl(), canvastest.Token, 10, canvas.WithTransport(replayer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replayed, err := replay.GetCoursesByAccountID(ctx, 1, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(recorded) != 15 || len(replayed) != len(recorded) {
+		t.Errorf("got %d replayed courses, want %d", len(replayed), len(recorded))
+	}
+}
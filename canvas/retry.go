@@ -0,0 +1,131 @@
+package canvas
+
+import (
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy configures how transient Canvas failures are retried.
+// Requests are retried on 429 Too Many Requests, 5xx responses, Canvas rate limit
+// rejections and connection resets, waiting with exponential backoff and jitter in between.
+type RetryPolicy struct {
+	MaxRetries int           // number of retries after the first attempt, 0 disables retries
+	BaseDelay  time.Duration // delay before the first retry, doubled on each retry
+	MaxDelay   time.Duration // upper bound of a single delay, including Retry-After
+}
+
+// DefaultRetryPolicy is used unless WithRetryPolicy is given.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	BaseDelay:  time.Millisecond * 500,
+	MaxDelay:   time.Second * 10,
+}
+
+// WithRetryPolicy sets the policy for retrying transient Canvas failures.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(o *clientOptions) {
+		o.retryPolicy = policy
+	}
+}
+
+// backoff returns the delay before the given retry, starting at 1.
+// Full jitter is applied so concurrent reports do not retry in lockstep.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.BaseDelay << (retry - 1)
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+
+	return rand.N(delay) + 1
+}
+
+// do sends the request, retrying transient failures according to the client's retry policy.
+// The request must have no body or must set GetBody so it can be replayed.
+func (c *CanvasClient) do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	for retry := 0; ; retry++ {
+		attempt := req.Clone(ctx)
+
+		if req.Body != nil && req.GetBody != nil && retry > 0 {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+
+			attempt.Body = body
+		}
+
+		res, err := c.httpClient.Do(attempt)
+
+		canRetry := retry < c.retryPolicy.MaxRetries && (req.Body == nil || req.GetBody != nil)
+
+		if !canRetry || !isRetryable(res, err) {
+			return res, err
+		}
+
+		delay := c.retryPolicy.backoff(retry + 1)
+
+		if res != nil {
+			if after, ok := parseRetryAfter(res.Header.Get("Retry-After")); ok {
+				delay = min(after, c.retryPolicy.MaxDelay)
+			}
+
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// isRetryable reports whether the failure is transient and the request is worth sending again.
+func isRetryable(res *http.Response, err error) bool {
+	if err != nil {
+		return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+	}
+
+	switch {
+	case res.StatusCode == http.StatusTooManyRequests:
+		return true
+	case res.StatusCode >= http.StatusInternalServerError:
+		return true
+	case res.StatusCode == http.StatusForbidden:
+		return isRateLimitExceeded(res)
+	}
+
+	return false
+}
+
+// parseRetryAfter parses the Retry-After header given either in seconds or as an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+
+	return 0, false
+}
@@ -2,10 +2,8 @@ package canvas
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
+	"iter"
 	"net/url"
 	"strconv"
 	"time"
@@ -29,7 +27,12 @@ type Section struct {
 
 // GetSectionsByCourseID retrieves sections of the given courseID.
 // Total number of active and invited students in the section is included.
-func (c *CanvasClient) GetSectionsByCourseID(ctx context.Context, courseID int) ([]*Section, int, error) {
+func (c *CanvasClient) GetSectionsByCourseID(ctx context.Context, courseID int) ([]*Section, error) {
+	return collect(c.IterSectionsByCourseID(ctx, courseID))
+}
+
+// IterSectionsByCourseID is like GetSectionsByCourseID but returns an iterator that fetches pages as they are consumed.
+func (c *CanvasClient) IterSectionsByCourseID(ctx context.Context, courseID int) iter.Seq2[*Section, error] {
 	params := url.Values{}
 
 	params.Add("page", "1")
@@ -38,86 +41,12 @@ func (c *CanvasClient) GetSectionsByCourseID(ctx context.Context, courseID int)
 
 	requestUrl := fmt.Sprintf("%s/courses/%d/sections?%s", c.baseUrl, courseID, params.Encode())
 
-	result := make([]*Section, 0)
-
-loop:
-	for {
-		select {
-		case <-ctx.Done():
-			return nil, http.StatusRequestTimeout, ctx.Err()
-		default:
-			{
-				req, err := http.NewRequest(http.MethodGet, requestUrl, nil)
-				if err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				res, err := c.httpClient.Do(req)
-				if err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				if res.StatusCode != http.StatusOK {
-					return nil, res.StatusCode, fmt.Errorf("error fetching sections of course: %d", courseID)
-				}
-
-				body, err := io.ReadAll(res.Body)
-				res.Body.Close()
-				if err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				var sections []*Section
-
-				if err := json.Unmarshal(body, &sections); err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				result = append(result, sections...)
-
-				nextUrl := getNextUrl(res.Header.Get("Link"))
-
-				if nextUrl == "" {
-					break loop
-				}
-
-				requestUrl = nextUrl
-			}
-		}
-	}
-
-	return result, http.StatusOK, nil
+	return paginate[Section](ctx, c, requestUrl, fmt.Sprintf("sections of course: %d", courseID))
 }
 
 // GetSectionByID retrieves section with given ID.
-func (c *CanvasClient) GetSectionByID(sectionID int) (Section, int, error) {
+func (c *CanvasClient) GetSectionByID(ctx context.Context, sectionID int) (Section, error) {
 	requestUrl := fmt.Sprintf("%s/sections/%d", c.baseUrl, sectionID)
 
-	req, err := http.NewRequest(http.MethodGet, requestUrl, nil)
-	if err != nil {
-		return Section{}, http.StatusInternalServerError, err
-	}
-
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return Section{}, http.StatusInternalServerError, err
-	}
-	defer res.Body.Close()
-
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return Section{}, http.StatusInternalServerError, err
-	}
-
-	if res.StatusCode != http.StatusOK {
-		return Section{}, res.StatusCode, fmt.Errorf("error fetching section: %d", sectionID)
-	}
-
-	var section Section
-
-	if err := json.Unmarshal(body, &section); err != nil {
-		return Section{}, http.StatusInternalServerError, err
-	}
-
-	return section, http.StatusInternalServerError, nil
+	return getOne[Section](ctx, c, requestUrl, fmt.Sprintf("section: %d", sectionID))
 }
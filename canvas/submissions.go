@@ -2,10 +2,8 @@ package canvas
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
+	"iter"
 	"net/url"
 	"strconv"
 
@@ -33,7 +31,10 @@ type Submission struct {
 	GradeMatchesCurrentSubmission bool        `json:"grade_matches_current_submission"`
 	GradedAt                      null.String `json:"graded_at"`
 	GraderID                      null.Int    `json:"grader_id"`
+	PostedAt                      null.String `json:"posted_at"` // null until the grade is visible to the student
 	Late                          bool        `json:"late"`
+	Missing                       bool        `json:"missing"`            // set by Canvas, like on the student's missing submissions
+	LatePolicyStatus              null.String `json:"late_policy_status"` // set by teachers: "late", "missing", "extended" or "none"
 	Excused                       null.Bool   `json:"excused"`
 	Assignment                    struct {
 		ID             int        `json:"id"`
@@ -42,63 +43,74 @@ type Submission struct {
 	} `json:"assignment"`
 }
 
-func (c *CanvasClient) GetSubmissionsByCourseID(ctx context.Context, courseID int, studentID int, submissionWorkflowState SubmissionWorkflowState) ([]*Submission, int, error) {
+// GetSubmissionsByCourseID retrieves submissions of the given student in the given course, or of every student when studentID is 0.
+// Assignment information of the submission is included. Submissions of every state are returned when the state is empty.
+func (c *CanvasClient) GetSubmissionsByCourseID(ctx context.Context, courseID int, studentID int, submissionWorkflowState SubmissionWorkflowState) ([]*Submission, error) {
+	return collect(c.IterSubmissionsByCourseID(ctx, courseID, studentID, submissionWorkflowState))
+}
+
+// IterSubmissionsByCourseID is like GetSubmissionsByCourseID but returns an iterator that fetches pages as they are consumed.
+func (c *CanvasClient) IterSubmissionsByCourseID(ctx context.Context, courseID int, studentID int, submissionWorkflowState SubmissionWorkflowState) iter.Seq2[*Submission, error] {
 	params := url.Values{}
 
 	params.Add("page", "1")
 	params.Add("per_page", strconv.Itoa(c.pageSize))
-	params.Add("student_ids[]", strconv.Itoa(studentID))
+
+	if studentID == 0 {
+		params.Add("student_ids[]", "all")
+	} else {
+		params.Add("student_ids[]", strconv.Itoa(studentID))
+	}
+
 	params.Add("include[]", "assignment")
-	params.Add("workflow_state", string(submissionWorkflowState))
+
+	if submissionWorkflowState != "" {
+		params.Add("workflow_state", string(submissionWorkflowState))
+	}
 
 	requestUrl := fmt.Sprintf("%s/courses/%d/students/submissions?%s", c.baseUrl, courseID, params.Encode())
 
-	results := make([]*Submission, 0)
-
-loop:
-	for {
-		select {
-		case <-ctx.Done():
-			return nil, http.StatusRequestTimeout, ctx.Err()
-		default:
-			{
-				req, err := http.NewRequest(http.MethodGet, requestUrl, nil)
-				if err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				res, err := c.httpClient.Do(req)
-				if err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				if res.StatusCode != http.StatusOK {
-					return nil, res.StatusCode, fmt.Errorf("error fetching submissions of course: %d and student: %d", courseID, studentID)
-				}
-
-				body, err := io.ReadAll(res.Body)
-				res.Body.Close()
-				if err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				var submissions []*Submission
-
-				if err := json.Unmarshal(body, &submissions); err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-
-				results = append(results, submissions...)
-
-				nextUrl := getNextUrl(res.Header.Get("Link"))
-				if nextUrl == "" {
-					break loop
-				}
-
-				requestUrl = nextUrl
-			}
-		}
+	return paginate[Submission](ctx, c, requestUrl, fmt.Sprintf("submissions of course: %d and student: %d", courseID, studentID))
+}
+
+// GetSubmissionsBySectionID retrieves submissions of every student in the given section.
+// Assignment information of the submission is included. Submissions of every state are returned when the state is empty.
+func (c *CanvasClient) GetSubmissionsBySectionID(ctx context.Context, sectionID int, submissionWorkflowState SubmissionWorkflowState) ([]*Submission, error) {
+	return collect(c.IterSubmissionsBySectionID(ctx, sectionID, submissionWorkflowState))
+}
+
+// IterSubmissionsBySectionID is like GetSubmissionsBySectionID but returns an iterator that fetches pages as they are consumed.
+func (c *CanvasClient) IterSubmissionsBySectionID(ctx context.Context, sectionID int, submissionWorkflowState SubmissionWorkflowState) iter.Seq2[*Submission, error] {
+	params := url.Values{}
+
+	params.Add("page", "1")
+	params.Add("per_page", strconv.Itoa(c.pageSize))
+	params.Add("student_ids[]", "all")
+	params.Add("include[]", "assignment")
+
+	if submissionWorkflowState != "" {
+		params.Add("workflow_state", string(submissionWorkflowState))
 	}
 
-	return results, http.StatusOK, nil
+	requestUrl := fmt.Sprintf("%s/sections/%d/students/submissions?%s", c.baseUrl, sectionID, params.Encode())
+
+	return paginate[Submission](ctx, c, requestUrl, fmt.Sprintf("submissions of section: %d", sectionID))
+}
+
+// GetMissingSubmissionsByUserID retrieves past due assignments the given student has not submitted, in all their courses.
+// Only assignments expecting a submission in Canvas are returned.
+func (c *CanvasClient) GetMissingSubmissionsByUserID(ctx context.Context, userID int) ([]*Assignment, error) {
+	return collect(c.IterMissingSubmissionsByUserID(ctx, userID))
+}
+
+// IterMissingSubmissionsByUserID is like GetMissingSubmissionsByUserID but returns an iterator that fetches pages as they are consumed.
+func (c *CanvasClient) IterMissingSubmissionsByUserID(ctx context.Context, userID int) iter.Seq2[*Assignment, error] {
+	params := url.Values{}
+
+	params.Add("per_page", strconv.Itoa(c.pageSize))
+	params.Add("filter[]", "submittable")
+
+	requestUrl := fmt.Sprintf("%s/users/%d/missing_submissions?%s", c.baseUrl, userID, params.Encode())
+
+	return paginate[Assignment](ctx, c, requestUrl, fmt.Sprintf("missing submissions of user: %d", userID))
 }
@@ -0,0 +1,63 @@
+package canvas
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/guregu/null/v5"
+)
+
+type TermWorkflowState string
+
+const (
+	ActiveTermWorkflowState  TermWorkflowState = "active"
+	DeletedTermWorkflowState TermWorkflowState = "deleted"
+)
+
+// Term is an enrollment term, e.g. "2026 Semester 2".
+type Term struct {
+	ID            int         `json:"id"`
+	Name          string      `json:"name"`
+	StartAt       null.Time   `json:"start_at"`
+	EndAt         null.Time   `json:"end_at"`
+	SISTermID     null.String `json:"sis_term_id"`
+	WorkflowState string      `json:"workflow_state"`
+}
+
+// Contains reports whether date falls within the term dates. Terms without a start or end date are open ended.
+func (t *Term) Contains(date time.Time) bool {
+	if t.StartAt.Valid && date.Before(t.StartAt.Time) {
+		return false
+	}
+
+	if t.EndAt.Valid && date.After(t.EndAt.Time) {
+		return false
+	}
+
+	return true
+}
+
+// GetTermsByAccountID retrieves enrollment terms of the given root account.
+// If "states" is not provided, only active terms are returned.
+func (c *CanvasClient) GetTermsByAccountID(ctx context.Context, accountID int, states []TermWorkflowState) ([]*Term, error) {
+	return collect(c.IterTermsByAccountID(ctx, accountID, states))
+}
+
+// IterTermsByAccountID is like GetTermsByAccountID but returns an iterator that fetches pages as they are consumed.
+func (c *CanvasClient) IterTermsByAccountID(ctx context.Context, accountID int, states []TermWorkflowState) iter.Seq2[*Term, error] {
+	params := url.Values{}
+
+	params.Add("per_page", strconv.Itoa(c.pageSize))
+
+	for _, state := range states {
+		params.Add("workflow_state[]", string(state))
+	}
+
+	requestUrl := fmt.Sprintf("%s/accounts/%d/terms?%s", c.baseUrl, accountID, params.Encode())
+
+	return paginate[Term](ctx, c, requestUrl, fmt.Sprintf("terms of account: %d", accountID))
+}
@@ -1,10 +1,8 @@
 package canvas
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
-	"net/http"
 	"time"
 )
 
@@ -21,34 +19,8 @@ type User struct {
 }
 
 // GetUserByID retrieves user with given ID.
-func (c *CanvasClient) GetUserByID(userID int) (User, int, error) {
+func (c *CanvasClient) GetUserByID(ctx context.Context, userID int) (User, error) {
 	requestUrl := fmt.Sprintf("%s/users/%d", c.baseUrl, userID)
 
-	req, err := http.NewRequest(http.MethodGet, requestUrl, nil)
-	if err != nil {
-		return User{}, http.StatusInternalServerError, err
-	}
-
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return User{}, http.StatusInternalServerError, err
-	}
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusOK {
-		return User{}, res.StatusCode, fmt.Errorf("error fetching user: %d", userID)
-	}
-
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return User{}, http.StatusInternalServerError, err
-	}
-
-	var user User
-
-	if err := json.Unmarshal(body, &user); err != nil {
-		return user, http.StatusInternalServerError, err
-	}
-
-	return user, http.StatusOK, nil
+	return getOne[User](ctx, c, requestUrl, fmt.Sprintf("user: %d", userID))
 }
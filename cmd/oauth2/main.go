@@ -0,0 +1,81 @@
+// Command oauth2 runs the Canvas OAuth2 authorization code flow of a developer key
+// and prints the refresh token to set as CANVAS_OAUTH2_REFRESH_TOKEN.
+package main
+
+import (
+	"canvas-report/canvas"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+func main() {
+	config := &canvas.OAuth2Config{
+		WebUrl:       os.Getenv("CANVAS_WEB_URL"),
+		ClientID:     os.Getenv("CANVAS_OAUTH2_CLIENT_ID"),
+		ClientSecret: os.Getenv("CANVAS_OAUTH2_CLIENT_SECRET"),
+		RedirectUrl:  "http://localhost:8081/oauth2/callback",
+	}
+
+	if config.WebUrl == "" {
+		panic("missing env: CANVAS_WEB_URL")
+	}
+
+	if config.ClientID == "" || config.ClientSecret == "" {
+		panic("missing env: CANVAS_OAUTH2_CLIENT_ID and CANVAS_OAUTH2_CLIENT_SECRET")
+	}
+
+	stateBytes := make([]byte, 16)
+
+	if _, err := rand.Read(stateBytes); err != nil {
+		panic(err)
+	}
+
+	state := hex.EncodeToString(stateBytes)
+
+	tokens := make(chan *canvas.OAuth2Token, 1)
+
+	http.HandleFunc("/oauth2/callback", func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+
+		if params.Get("state") != state {
+			http.Error(w, "invalid state", http.StatusBadRequest)
+			return
+		}
+
+		if params.Get("error") != "" {
+			http.Error(w, params.Get("error"), http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), time.Second*10)
+		defer cancel()
+
+		token, err := config.Exchange(ctx, params.Get("code"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		w.Write([]byte("Authorized, you can close this window."))
+
+		tokens <- token
+	})
+
+	go func() {
+		if err := http.ListenAndServe("localhost:8081", nil); err != nil {
+			log.Fatalf("error listen and serve: %s\n", err)
+		}
+	}()
+
+	fmt.Printf("Open this url to authorize the developer key:\n\n%s\n\n", config.AuthCodeURL(state))
+
+	token := <-tokens
+
+	fmt.Printf("CANVAS_OAUTH2_REFRESH_TOKEN=%s\n", token.RefreshToken)
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"canvas-report/api"
-	"canvas-report/canvas"
 	"context"
 	"errors"
 	"fmt"
@@ -10,41 +9,12 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"strconv"
 	"syscall"
 	"time"
 )
 
 func main() {
-	canvasBaseUrl := os.Getenv("CANVAS_BASE_URL")
-	if canvasBaseUrl == "" {
-		panic("missing env: CANVAS_BASE_URL")
-	}
-
-	canvasAccessToken := os.Getenv("CANVAS_ACCESS_TOKEN")
-	if canvasAccessToken == "" {
-		panic("missing env: CANVAS_ACCESS_TOKEN")
-	}
-
-	// Number of items to fetch per request when paginating with the Canvas API.
-	pageSize := 100
-
-	pageSizeEnv := os.Getenv("CANVAS_PAGE_SIZE")
-	if pageSizeEnv != "" {
-		value, err := strconv.Atoi(pageSizeEnv)
-		if err != nil {
-			panic("invalid env: CANVAS_PAGE_SIZE")
-		}
-
-		pageSize = value
-	}
-
-	canvasClient, err := canvas.NewCanvasClient(canvasBaseUrl, canvasAccessToken, pageSize)
-	if err != nil {
-		panic(fmt.Errorf("error creating canvas client: %w", err))
-	}
-
-	apiController, err := api.NewAPIController(canvasClient, nil)
+	apiController, err := api.NewAPIControllerFromEnv(os.Getenv)
 	if err != nil {
 		panic(fmt.Errorf("error creating api controller: %w", err))
 	}